@@ -10,6 +10,7 @@ import (
 
 	"github.com/connerdouglass/livechat-api/models"
 	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/utils"
 	v1 "github.com/connerdouglass/livechat-api/v1"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -55,10 +56,19 @@ func main() {
 	db.AutoMigrate(
 		&models.Account{},
 		&models.Badge{},
+		&models.BadgeAssignment{},
 		&models.BannedWord{},
+		&models.ChatMessage{},
 		&models.ChatRoom{},
+		&models.ChatUser{},
+		&models.Emote{},
+		&models.LinkDomain{},
+		&models.ModerationEvent{},
 		&models.MutedUser{},
 		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.ReservedName{},
+		&models.SpamRule{},
 	)
 
 	//================================================================================
@@ -85,23 +95,58 @@ func main() {
 	// Create all the service instances
 	//================================================================================
 
+	// Create the resolver for client IP addresses, which only trusts forwarding headers from known proxies
+	ipResolver, err := utils.NewIpResolver(getEnvList("TRUSTED_PROXIES"), os.Getenv("TRUSTED_IP_HEADER"))
+	if err != nil {
+		log.Fatalln("Failed to parse TRUSTED_PROXIES: ", err)
+	}
+
+	// If a key is provided, IP addresses are stored as keyed hashes instead of raw addresses
+	ipHasher := &utils.IpHasher{Key: os.Getenv("IP_HASH_KEY")}
+
 	chatService := &services.ChatService{
-		DB: db,
+		DB:       db,
+		IpHasher: ipHasher,
 	}
-	socketsService := &services.SocketsService{
-		Server:      socketIoServer,
+	moderationService := &services.ModerationService{
+		DB:       db,
+		IpHasher: ipHasher,
+	}
+	organizationsService := &services.OrganizationsService{DB: db}
+	privacyService := &services.PrivacyService{
+		DB:          db,
+		IpHasher:    ipHasher,
 		ChatService: chatService,
 	}
+	retentionService := &services.RetentionService{DB: db}
 	accountsService := &services.AccountsService{DB: db}
+	assetsService := &services.AssetsService{
+		Storage: &utils.LocalBlobStorage{Dir: getEnvDefault("ASSETS_DIR", "assets")},
+		BaseURL: getEnvDefault("ASSETS_BASE_URL", "/v1/assets"),
+	}
 	authTokensService := &services.AuthTokensService{
 		DB:            db,
 		SigningPepper: os.Getenv("AUTH_TOKEN_SIGNING_PEPPER"),
 	}
+	socketsService := &services.SocketsService{
+		Server:               socketIoServer,
+		IpResolver:           ipResolver,
+		AuthTokensService:    authTokensService,
+		ChatService:          chatService,
+		ModerationService:    moderationService,
+		OrganizationsService: organizationsService,
+	}
 
 	// Do some final update on the sockets service
 	// Needed because it has a circular relationship with other services
 	socketsService.Setup()
 
+	// Start marking expired mutes in the background
+	go socketsService.RunMuteSweeper(time.Minute)
+
+	// Start enforcing data retention policies in the background
+	go retentionService.RunPurgeJob(time.Hour)
+
 	//================================================================================
 	// Setup the Gin HTTP router
 	//================================================================================
@@ -118,9 +163,16 @@ func main() {
 
 	// Create the API instance
 	api := &v1.Server{
-		AccountsService:   accountsService,
-		AuthTokensService: authTokensService,
-		ChatService:       chatService,
+		IpResolver:           ipResolver,
+		AccountsService:      accountsService,
+		AssetsService:        assetsService,
+		AuthTokensService:    authTokensService,
+		ChatService:          chatService,
+		ModerationService:    moderationService,
+		OrganizationsService: organizationsService,
+		PrivacyService:       privacyService,
+		RetentionService:     retentionService,
+		SocketsService:       socketsService,
 	}
 
 	// Mount the API routes
@@ -140,24 +192,41 @@ func main() {
 
 // GetAllowedOrigins gets the slice of allowed CORS origins
 func GetAllowedOrigins() []string {
+	return getEnvList("CORS_ALLOW_ORIGINS")
+}
+
+// getEnvDefault gets the value of an environment variable, or the fallback if it isn't set
+func getEnvDefault(key, fallback string) string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if len(value) == 0 {
+		return fallback
+	}
+	return value
+}
+
+// getEnvList gets a comma-separated list of values from an environment variable
+func getEnvList(key string) []string {
 
-	// Get the list of origins allowed
-	env, ok := os.LookupEnv("CORS_ALLOW_ORIGINS")
+	// Get the raw value of the list
+	env, ok := os.LookupEnv(key)
 	if !ok {
 		return []string{}
 	}
 
 	// Create the slice for it
-	origins := []string{}
+	values := []string{}
 
 	// Split up the env value
-	originsRaw := strings.Split(env, ",")
-	for _, originRaw := range originsRaw {
-		origin := strings.TrimSpace(originRaw)
-		origins = append(origins, origin)
+	valuesRaw := strings.Split(env, ",")
+	for _, valueRaw := range valuesRaw {
+		value := strings.TrimSpace(valueRaw)
+		if len(value) == 0 {
+			continue
+		}
+		values = append(values, value)
 	}
 
-	// Return the origins slice
-	return origins
+	// Return the values slice
+	return values
 
 }
@@ -9,8 +9,12 @@ import (
 
 // Account is an admin account on the platform
 type Account struct {
-	ID           uint64 `gorm:"primaryKey"`
-	Email        string
+	ID    uint64 `gorm:"primaryKey"`
+	Email string
+
+	// DisplayName is the name the account goes by in chat
+	DisplayName string
+
 	PasswordSalt string
 	PasswordHash string
 	CreatedDate  time.Time
@@ -10,6 +10,7 @@ type Badge struct {
 	ID              uint64 `gorm:"primaryKey"`
 	OrganizationID  uint64
 	Organization    *Organization
+	Name            string
 	BackgroundColor string
 	Image           string
 	CreatedDate     time.Time
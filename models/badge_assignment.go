@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// ChatRoleNone is a badge assignment without a role
+	ChatRoleNone = ""
+
+	// ChatRoleModerator is a chatter who moderates the chat
+	ChatRoleModerator = "moderator"
+
+	// ChatRoleVip is a chatter given special standing by the streamer
+	ChatRoleVip = "vip"
+
+	// ChatRoleSubscriber is a chatter who subscribes to the streamer
+	ChatRoleSubscriber = "subscriber"
+)
+
+// IsValidChatRole checks if a role is one of the known badge assignment roles
+func IsValidChatRole(role string) bool {
+	switch role {
+	case ChatRoleNone, ChatRoleModerator, ChatRoleVip, ChatRoleSubscriber:
+		return true
+	}
+	return false
+}
+
+// BadgeAssignment gives a badge to a chatter, identified either by their username (stored in lowercase) or by the
+// account they are signed in with. If ChatRoomID is set, the badge is only shown in that chat room. Role optionally
+// records the role in the chat that the badge stands for.
+type BadgeAssignment struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64
+	Organization   *Organization
+	BadgeID        uint64
+	Badge          *Badge
+	Username       sql.NullString
+	AccountID      sql.NullInt64
+	Account        *Account
+	ChatRoomID     sql.NullInt64
+	ChatRoom       *ChatRoom
+	Role           string
+	CreatedDate    time.Time
+	DeletedDate    sql.NullTime
+}
@@ -5,7 +5,8 @@ import (
 	"time"
 )
 
-// BannedWord represents a word or phrase that is banned in chat
+// BannedWord represents a word or phrase that is banned in chat. A word with FlagOnly set is suspicious rather than
+// banned: messages containing it are allowed, but held for review in rooms that hold flagged messages.
 type BannedWord struct {
 	ID                   uint64 `gorm:"primaryKey"`
 	OrganizationID       sql.NullInt64
@@ -13,6 +14,7 @@ type BannedWord struct {
 	Word                 string
 	TemporaryMuteSeconds sql.NullInt64
 	PermanentBan         bool
+	FlagOnly             bool
 	CreatedDate          time.Time
 	DeletedDate          sql.NullTime
 }
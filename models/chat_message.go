@@ -0,0 +1,35 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ChatMessage is a message that was sent in a chat room, kept as part of the room's chat history
+type ChatMessage struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64
+	Organization   *Organization
+	ChatRoomID     uint64
+	ChatRoom       *ChatRoom
+	MessageID      string `gorm:"index"`
+	Username       string
+	PhotoUrl       string
+
+	// AccountID is the account of the sender, if they were signed in
+	AccountID sql.NullInt64
+	IpAddress string
+
+	// IpNetwork is the stored form of the sender's IPv6 /64 network, so grouped purges still match hashed addresses
+	IpNetwork string
+	Message   string
+
+	// ReplyToMessageID is the message ID of the message this one replies to, if any
+	ReplyToMessageID sql.NullString
+	CreatedDate      time.Time
+
+	// EditedDate is set once the sender has edited the message
+	EditedDate  sql.NullTime
+	ClearedDate sql.NullTime
+	DeletedDate sql.NullTime
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// TrustLevelRestricted is a chatter who has been muted several times
+	TrustLevelRestricted = "restricted"
+
+	// TrustLevelNew is a chatter who was first seen recently, or has only sent a few messages
+	TrustLevelNew = "new"
+
+	// TrustLevelRegular is a chatter who has been around for a while
+	TrustLevelRegular = "regular"
+
+	// TrustLevelTrusted is a long-time chatter who has never been muted
+	TrustLevelTrusted = "trusted"
+)
+
+// Thresholds used to compute the trust level of a chatter
+const (
+	restrictedMutes        = 3
+	newChatterAge          = 24 * time.Hour
+	newChatterMessages     = 5
+	trustedChatterAge      = 30 * 24 * time.Hour
+	trustedChatterMessages = 100
+)
+
+// ChatUser is the profile of a chatter within an organization, built up as they chat
+type ChatUser struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64 `gorm:"uniqueIndex:idx_chat_user_identity"`
+	Organization   *Organization
+
+	// Username is stored in lowercase, since usernames are matched without case
+	Username      string `gorm:"uniqueIndex:idx_chat_user_identity"`
+	FirstSeenDate time.Time
+	LastSeenDate  time.Time
+	MessageCount  int64
+	MutesReceived int64
+
+	// OwnerAccountID or OwnerIpAddress is who first chatted under the username, and the only one its history counts for
+	OwnerAccountID sql.NullInt64
+	OwnerIpAddress sql.NullString
+
+	// FollowerAccountID or FollowerIpAddress is who followed, and the only one who can unfollow
+	FollowedDate      sql.NullTime
+	FollowerAccountID sql.NullInt64
+	FollowerIpAddress sql.NullString
+}
+
+// TrustLevel computes how much a chatter is trusted, based on their history in the organization
+func (u *ChatUser) TrustLevel(now time.Time) string {
+	age := now.Sub(u.FirstSeenDate)
+	switch {
+	case u.MutesReceived >= restrictedMutes:
+		return TrustLevelRestricted
+	case age < newChatterAge || u.MessageCount < newChatterMessages:
+		return TrustLevelNew
+	case age >= trustedChatterAge && u.MessageCount >= trustedChatterMessages && u.MutesReceived == 0:
+		return TrustLevelTrusted
+	}
+	return TrustLevelRegular
+}
+
+// IsNewChatter checks if a chatter hasn't earned the trust of a regular chatter yet
+func (u *ChatUser) IsNewChatter(now time.Time) bool {
+	level := u.TrustLevel(now)
+	return level == TrustLevelNew || level == TrustLevelRestricted
+}
+
+// IsFollowing checks if a chatter has been following the organization for at least the given duration
+func (u *ChatUser) IsFollowing(minimum time.Duration, now time.Time) bool {
+	return u.FollowedDate.Valid && now.Sub(u.FollowedDate.Time) >= minimum
+}
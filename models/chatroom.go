@@ -5,6 +5,20 @@ import (
 	"time"
 )
 
+const (
+	// HoldModeOff broadcasts messages as soon as they pass moderation
+	HoldModeOff = ""
+
+	// HoldModeAll holds every message for review by a moderator
+	HoldModeAll = "all"
+
+	// HoldModeFirstTime holds messages from chatters who haven't chatted in the organization before
+	HoldModeFirstTime = "first_time"
+
+	// HoldModeFlagged holds messages that automod flagged as suspicious
+	HoldModeFlagged = "flagged"
+)
+
 // ChatRoom represents a single chat room, with a unique chat history
 type ChatRoom struct {
 	ID             uint64 `gorm:"primaryKey"`
@@ -13,6 +27,55 @@ type ChatRoom struct {
 	Identifier     string
 	Title          string
 	CurrentUsers   int
-	CreatedDate    time.Time
-	DeletedDate    sql.NullTime
+
+	// The pinned message, if any, shown until PinnedUntilDate if that is set
+	PinnedMessageID sql.NullString
+	PinnedUsername  sql.NullString
+	PinnedPhotoUrl  sql.NullString
+	PinnedMessage   sql.NullString
+	PinnedUntilDate sql.NullTime
+
+	// HoldMode decides which messages are held for review by a moderator instead of being broadcast
+	HoldMode string
+
+	// ProofOfWork makes viewers solve a challenge before they can chat, to slow down bots
+	ProofOfWork bool
+
+	// NewChatterWaitMinutes is how long after first being seen a chatter must wait before chatting
+	NewChatterWaitMinutes int64
+
+	// BlockNewChatterLinks stops new chatters from posting links
+	BlockNewChatterLinks bool
+
+	// FollowersOnlyMinutes, if set, limits the chat to chatters who have followed for at least that long
+	FollowersOnlyMinutes sql.NullInt64
+
+	// EditWindowSeconds is how long senders can edit their messages for, where zero turns editing off
+	EditWindowSeconds int64
+
+	CreatedDate time.Time
+	DeletedDate sql.NullTime
+}
+
+// HasActivePin checks if the chat room has a pinned message that has not expired
+func (r *ChatRoom) HasActivePin() bool {
+	if !r.PinnedMessageID.Valid {
+		return false
+	}
+	if r.PinnedUntilDate.Valid && r.PinnedUntilDate.Time.Before(time.Now()) {
+		return false
+	}
+	return true
+}
+
+// MaxEditWindowSeconds is the longest time a chat room can let senders edit their messages for
+const MaxEditWindowSeconds = 15 * 60
+
+// IsValidHoldMode checks if a hold mode is one of the supported modes
+func IsValidHoldMode(mode string) bool {
+	switch mode {
+	case HoldModeOff, HoldModeAll, HoldModeFirstTime, HoldModeFlagged:
+		return true
+	}
+	return false
 }
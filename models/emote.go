@@ -0,0 +1,18 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Emote is a custom image that chatters can use in an organization's chat rooms by typing its code between colons,
+// like ":hype:". Codes are case sensitive.
+type Emote struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64
+	Organization   *Organization
+	Code           string
+	Image          string
+	CreatedDate    time.Time
+	DeletedDate    sql.NullTime
+}
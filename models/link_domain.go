@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// LinkPolicyOff allows links to any domain
+	LinkPolicyOff = ""
+
+	// LinkPolicyBlock blocks every message with a link
+	LinkPolicyBlock = "block"
+
+	// LinkPolicyAllowList blocks links to any domain that isn't on the allow list
+	LinkPolicyAllowList = "allow_list"
+
+	// LinkPolicyBlockList blocks links to the domains on the block list
+	LinkPolicyBlockList = "block_list"
+
+	// LinkPolicyHold holds messages with links for review, unless the domains are on the allow list
+	LinkPolicyHold = "hold"
+)
+
+// LinkDomain is a domain on the allow list or the block list of an organization. Subdomains of the domain are
+// included.
+type LinkDomain struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64
+	Organization   *Organization
+	Domain         string
+	Allowed        bool
+	CreatedDate    time.Time
+	DeletedDate    sql.NullTime
+}
+
+// IsValidLinkPolicy checks if a link policy is one of the supported policies
+func IsValidLinkPolicy(policy string) bool {
+	switch policy {
+	case LinkPolicyOff, LinkPolicyBlock, LinkPolicyAllowList, LinkPolicyBlockList, LinkPolicyHold:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// ModerationActorAccount is an action taken by a moderator's account
+	ModerationActorAccount = "account"
+
+	// ModerationActorAutomod is an action taken automatically by an automod rule
+	ModerationActorAutomod = "automod"
+
+	// ModerationActorViewer is an action taken by a viewer in the chat
+	ModerationActorViewer = "viewer"
+)
+
+const (
+	ModerationActionMute     = "mute"
+	ModerationActionTimeout  = "timeout"
+	ModerationActionUnmute   = "unmute"
+	ModerationActionEditMute = "edit_mute"
+	ModerationActionRevoke   = "revoke"
+	ModerationActionPurge    = "purge"
+	ModerationActionClear    = "clear"
+	ModerationActionErase    = "erase"
+	ModerationActionApprove  = "approve"
+	ModerationActionReject   = "reject"
+)
+
+// ModerationEvent is an entry in the append-only log of moderation actions taken in an organization
+type ModerationEvent struct {
+	ID              uint64 `gorm:"primaryKey"`
+	OrganizationID  uint64
+	Organization    *Organization
+	ChatRoomID      sql.NullInt64
+	ChatRoom        *ChatRoom
+	ActorType       string
+	ActorAccountID  sql.NullInt64
+	ActorAccount    *Account
+	ActorRule       sql.NullString
+	ActorUsername   sql.NullString
+	Action          string
+	TargetUsername  sql.NullString
+	TargetIpAddress sql.NullString
+	Reason          string
+	MessageID       sql.NullString
+	CreatedDate     time.Time
+}
@@ -5,14 +5,41 @@ import (
 	"time"
 )
 
+const (
+	// MuteTypeStandard is a mute where the user's messages are dropped
+	MuteTypeStandard = "standard"
+
+	// MuteTypeShadow is a mute where the user's messages are only shown back to the user, so it looks to them
+	// like their messages are still being delivered
+	MuteTypeShadow = "shadow"
+)
+
 // MutedUser is a user that is muted in chat
 type MutedUser struct {
 	ID             uint64 `gorm:"primaryKey"`
 	OrganizationID uint64
 	Organization   *Organization
+	ChatRoomID     sql.NullInt64
+	ChatRoom       *ChatRoom
 	Username       sql.NullString
 	IpAddress      sql.NullString
+	Type           string
+	Reason         string
 	UntilDate      sql.NullTime
+	ExpiredDate    sql.NullTime
 	CreatedDate    time.Time
 	DeletedDate    sql.NullTime
 }
+
+// IsActive checks if the mute is still in effect
+func (m *MutedUser) IsActive() bool {
+	if m.DeletedDate.Valid {
+		return false
+	}
+	return !m.UntilDate.Valid || m.UntilDate.Time.After(time.Now())
+}
+
+// IsShadow checks if the mute is a shadow mute
+func (m *MutedUser) IsShadow() bool {
+	return m.Type == MuteTypeShadow
+}
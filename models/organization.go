@@ -8,10 +8,22 @@ import (
 // Organization is a company or individual profile, which can contain
 // multiple chat rooms within it.
 type Organization struct {
-	ID          uint64 `gorm:"primaryKey"`
-	AccountID   uint64
-	Account     *Account
-	Name        string
+	ID        uint64 `gorm:"primaryKey"`
+	AccountID uint64
+	Account   *Account
+	Name      string
+
+	// Days each kind of data is kept before being purged, where null keeps it forever
+	MessageRetentionDays sql.NullInt64
+	MuteRetentionDays    sql.NullInt64
+	LogRetentionDays     sql.NullInt64
+
+	// LinkPolicy decides what happens to messages with links in them
+	LinkPolicy string
+
+	// Reactions is the space-separated set of emoji viewers can react with, where empty uses the default set
+	Reactions string
+
 	CreatedDate time.Time
 	DeletedDate sql.NullTime
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// RoleOwner is the role of the account that owns an organization
+	RoleOwner = "owner"
+
+	// RoleAdmin is the role of an account that can manage an organization's settings
+	RoleAdmin = "admin"
+
+	// RoleModerator is the role of an account that can moderate an organization's chat rooms
+	RoleModerator = "moderator"
+)
+
+// roleRanks orders the roles from least to most privileged
+var roleRanks = map[string]int{
+	RoleModerator: 1,
+	RoleAdmin:     2,
+	RoleOwner:     3,
+}
+
+// RoleAtLeast checks if a role has at least the privileges of the minimum role
+func RoleAtLeast(role, minimum string) bool {
+	rank, ok := roleRanks[role]
+	if !ok {
+		return false
+	}
+	return rank >= roleRanks[minimum]
+}
+
+// OrganizationMember grants an account a role within an organization
+type OrganizationMember struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64
+	Organization   *Organization
+	AccountID      uint64
+	Account        *Account
+	Role           string
+	CreatedDate    time.Time
+	DeletedDate    sql.NullTime
+}
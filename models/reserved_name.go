@@ -0,0 +1,17 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReservedName is a name that nobody can chat under in an organization's chat rooms, nor under any name that
+// looks like it. The organization's name and the display names of its members are protected the same way.
+type ReservedName struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64
+	Organization   *Organization
+	Name           string
+	CreatedDate    time.Time
+	DeletedDate    sql.NullTime
+}
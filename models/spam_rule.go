@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// SpamHeuristicDuplicate catches the same message sent more than Threshold times by a user within
+	// WindowSeconds
+	SpamHeuristicDuplicate = "duplicate"
+
+	// SpamHeuristicCaps catches messages where more than the Threshold fraction of the letters are uppercase.
+	// Messages with fewer than MinLength letters are ignored.
+	SpamHeuristicCaps = "caps"
+
+	// SpamHeuristicSymbols catches messages where more than the Threshold fraction of the characters are emoji,
+	// symbols or punctuation. Messages with fewer than MinLength characters are ignored.
+	SpamHeuristicSymbols = "symbols"
+
+	// SpamHeuristicLength catches messages longer than Threshold characters
+	SpamHeuristicLength = "length"
+
+	// SpamHeuristicRepeatedChars catches messages repeating the same character more than Threshold times in a row
+	SpamHeuristicRepeatedChars = "repeated_chars"
+)
+
+const (
+	// SpamActionBlock blocks the message
+	SpamActionBlock = "block"
+
+	// SpamActionHold holds the message for review by a moderator
+	SpamActionHold = "hold"
+
+	// SpamActionTimeout blocks the message and mutes the sender for TimeoutSeconds
+	SpamActionTimeout = "timeout"
+)
+
+// SpamRule is an automod heuristic that an organization applies to the messages in its chat rooms
+type SpamRule struct {
+	ID             uint64 `gorm:"primaryKey"`
+	OrganizationID uint64
+	Organization   *Organization
+	Heuristic      string
+	Threshold      float64
+	MinLength      int
+	WindowSeconds  int64
+	Action         string
+	TimeoutSeconds sql.NullInt64
+	CreatedDate    time.Time
+	DeletedDate    sql.NullTime
+}
+
+// IsValidSpamHeuristic checks if a heuristic is one of the supported heuristics
+func IsValidSpamHeuristic(heuristic string) bool {
+	switch heuristic {
+	case SpamHeuristicDuplicate, SpamHeuristicCaps, SpamHeuristicSymbols, SpamHeuristicLength, SpamHeuristicRepeatedChars:
+		return true
+	}
+	return false
+}
+
+// IsValidSpamAction checks if an action is one of the supported actions
+func IsValidSpamAction(action string) bool {
+	switch action {
+	case SpamActionBlock, SpamActionHold, SpamActionTimeout:
+		return true
+	}
+	return false
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/connerdouglass/livechat-api/models"
 	"gorm.io/gorm"
@@ -56,3 +59,25 @@ func (s *AccountsService) FindByLogin(email, password string) (*models.Account,
 	return &account, nil
 
 }
+
+// maxDisplayNameLength is the most characters allowed in a display name
+const maxDisplayNameLength = 64
+
+// UpdateDisplayName changes the name an account goes by in chat. An empty name clears it.
+func (s *AccountsService) UpdateDisplayName(account *models.Account, displayName string) error {
+
+	// Check the name
+	displayName = strings.TrimSpace(displayName)
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+		return fmt.Errorf("display name cannot be longer than %d characters", maxDisplayNameLength)
+	}
+
+	// Save the name
+	account.DisplayName = displayName
+	return s.DB.
+		Model(account).
+		Select("display_name").
+		Updates(account).
+		Error
+
+}
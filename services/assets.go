@@ -0,0 +1,85 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+
+	"github.com/connerdouglass/livechat-api/utils"
+)
+
+const (
+	// BadgeImageSize is the width and height, in pixels, that badge images are resized to
+	BadgeImageSize = 72
+
+	// EmoteImageSize is the width and height, in pixels, that emote images are resized to
+	EmoteImageSize = 112
+
+	// MaxImageUploadBytes is the largest image that can be uploaded
+	MaxImageUploadBytes = 1 << 20
+
+	// maxImageUploadDimension is the widest or tallest image accepted before resizing
+	maxImageUploadDimension = 2048
+)
+
+// AssetsService stores uploaded assets, like badge images, and serves them back. Asset keys are derived from the
+// content, so an asset never changes once stored and can be cached indefinitely. Identical uploads share a key, so
+// assets are never deleted when a badge moves on to a new image.
+type AssetsService struct {
+	Storage utils.BlobStorage
+	BaseURL string
+}
+
+// URL gets the public URL of the asset with a key
+func (s *AssetsService) URL(key string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + key
+}
+
+// GetAsset gets the data of the asset with a key
+func (s *AssetsService) GetAsset(key string) ([]byte, error) {
+	return s.Storage.Get(key)
+}
+
+// StoreBadgeImage checks an uploaded badge image, resizes it to the standard badge size, and stores it as a PNG.
+// The public URL of the stored image is returned.
+func (s *AssetsService) StoreBadgeImage(data []byte) (string, error) {
+	return s.storeImage("badges", BadgeImageSize, data)
+}
+
+// StoreEmoteImage checks an uploaded emote image, resizes it to the standard emote size, and stores it as a PNG.
+// The public URL of the stored image is returned.
+func (s *AssetsService) StoreEmoteImage(data []byte) (string, error) {
+	return s.storeImage("emotes", EmoteImageSize, data)
+}
+
+// storeImage checks an uploaded image, resizes it to fit a square of the given size, and stores it as a PNG in a
+// folder
+func (s *AssetsService) storeImage(folder string, size int, data []byte) (string, error) {
+
+	// Check the size of the upload
+	if len(data) > MaxImageUploadBytes {
+		return "", fmt.Errorf("image must be at most %d bytes", MaxImageUploadBytes)
+	}
+
+	// Decode the image, and resize it to fit the square
+	img, err := utils.DecodeImage(data, maxImageUploadDimension)
+	if err != nil {
+		return "", err
+	}
+	resized := utils.FitImage(img, size)
+
+	// Encode the resized image
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, resized); err != nil {
+		return "", err
+	}
+
+	// Store the image under a key derived from its content
+	key := folder + "/" + utils.Sha256Hex(buf.String()) + ".png"
+	if err := s.Storage.Put(key, buf.Bytes()); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+
+}
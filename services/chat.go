@@ -3,22 +3,33 @@ package services
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
 	"gorm.io/gorm"
 )
 
+// ChatUserInfo identifies a chatter. Account is the account a chatter is signed in with, if any, which is only
+// ever set from their socket connection.
 type ChatUserInfo struct {
-	Username  string `json:"username"`
-	IpAddress string `json:"ip_address"`
+	Username  string          `json:"username"`
+	IpAddress string          `json:"ip_address"`
+	Account   *models.Account `json:"-"`
 }
 
 // ChatService manages chat moderation
 type ChatService struct {
-	DB *gorm.DB
+	DB                *gorm.DB
+	IpHasher          *utils.IpHasher
+	ipMutes           map[uint64]*ipMuteCache
+	ipMutesMut        sync.Mutex
+	protectedNames    map[uint64]*protectedNameCache
+	protectedNamesMut sync.Mutex
 }
 
 // GetChatRoomByIdentifier gets the chat room with the provided identifier
@@ -38,10 +49,131 @@ func (s *ChatService) GetChatRoomByIdentifier(identifier string) (*models.ChatRo
 	return &chatRoom, nil
 }
 
+// GetChatRoomByID gets the chat room with the provided ID
+func (s *ChatService) GetChatRoomByID(id uint64) (*models.ChatRoom, error) {
+	var chatRoom models.ChatRoom
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&chatRoom).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &chatRoom, nil
+}
+
+// GetChatRoomsByOrganization gets all of the chat rooms in an organization
+func (s *ChatService) GetChatRoomsByOrganization(organizationID uint64) ([]*models.ChatRoom, error) {
+	var chatRooms []*models.ChatRoom
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Find(&chatRooms).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return chatRooms, nil
+}
+
+// ChatRoomSettings holds changes to the moderation settings of a chat room. Nil fields are left unchanged.
+type ChatRoomSettings struct {
+	HoldMode              *string
+	ProofOfWork           *bool
+	NewChatterWaitMinutes *int64
+	BlockNewChatterLinks  *bool
+	FollowersOnlyMinutes  *sql.NullInt64
+	EditWindowSeconds     *int64
+}
+
+// UpdateChatRoomSettings changes the moderation settings of a chat room
+func (s *ChatService) UpdateChatRoomSettings(chatRoom *models.ChatRoom, settings *ChatRoomSettings) error {
+
+	// Apply each of the changed settings
+	columns := []interface{}{}
+	if settings.HoldMode != nil {
+		if !models.IsValidHoldMode(*settings.HoldMode) {
+			return errors.New("invalid hold mode")
+		}
+		chatRoom.HoldMode = *settings.HoldMode
+		columns = append(columns, "hold_mode")
+	}
+
+	if settings.ProofOfWork != nil {
+		chatRoom.ProofOfWork = *settings.ProofOfWork
+		columns = append(columns, "proof_of_work")
+	}
+
+	if settings.NewChatterWaitMinutes != nil {
+		if *settings.NewChatterWaitMinutes < 0 {
+			return errors.New("new chatter wait cannot be negative")
+		}
+		chatRoom.NewChatterWaitMinutes = *settings.NewChatterWaitMinutes
+		columns = append(columns, "new_chatter_wait_minutes")
+	}
+	if settings.BlockNewChatterLinks != nil {
+		chatRoom.BlockNewChatterLinks = *settings.BlockNewChatterLinks
+		columns = append(columns, "block_new_chatter_links")
+	}
+	if settings.FollowersOnlyMinutes != nil {
+		if settings.FollowersOnlyMinutes.Valid && settings.FollowersOnlyMinutes.Int64 < 0 {
+			return errors.New("followers-only minutes cannot be negative")
+		}
+		chatRoom.FollowersOnlyMinutes = *settings.FollowersOnlyMinutes
+		columns = append(columns, "followers_only_minutes")
+	}
+
+	if settings.EditWindowSeconds != nil {
+		if *settings.EditWindowSeconds < 0 || *settings.EditWindowSeconds > models.MaxEditWindowSeconds {
+			return fmt.Errorf("edit window must be between 0 and %d seconds", models.MaxEditWindowSeconds)
+		}
+		chatRoom.EditWindowSeconds = *settings.EditWindowSeconds
+		columns = append(columns, "edit_window_seconds")
+	}
+
+	// If nothing changed, there's nothing to save
+	if len(columns) == 0 {
+		return nil
+	}
+
+	// Save the changes
+	return s.DB.
+		Model(chatRoom).
+		Select(columns[0], columns[1:]...).
+		Updates(chatRoom).
+		Error
+
+}
+
+// MuteOptions configures a new mute
+type MuteOptions struct {
+
+	// ChatRoom limits the mute to a single chat room. If nil, the mute applies to every chat room in the
+	// organization
+	ChatRoom *models.ChatRoom
+
+	// UntilDate is when the mute ends. If nil, the mute is permanent
+	UntilDate *time.Time
+
+	// Reason is the reason given for the mute
+	Reason string
+
+	// Shadow makes the mute a shadow mute, where the user's messages are only shown back to themself
+	Shadow bool
+
+	// GroupIpv6 widens an IPv6 address to its /64 network, since IPv6 users can rotate through addresses
+	// within it
+	GroupIpv6 bool
+}
+
 func (s *ChatService) MuteUser(
 	organizationID uint64,
 	user *ChatUserInfo,
-	untilDate *time.Time,
+	opts *MuteOptions,
 ) (*models.MutedUser, error) {
 
 	// If the user info is missing both fields
@@ -51,19 +183,34 @@ func (s *ChatService) MuteUser(
 
 	// Create the until date
 	var until sql.NullTime
-	if untilDate != nil {
+	if opts.UntilDate != nil {
 		until = sql.NullTime{
 			Valid: true,
-			Time:  *untilDate,
+			Time:  *opts.UntilDate,
+		}
+	}
+
+	// Create the chat room scope
+	var chatRoomID sql.NullInt64
+	if opts.ChatRoom != nil {
+		chatRoomID = sql.NullInt64{
+			Valid: true,
+			Int64: int64(opts.ChatRoom.ID),
 		}
 	}
 
 	// Add an entry to mute the user
 	mutedUser := models.MutedUser{
 		OrganizationID: organizationID,
+		ChatRoomID:     chatRoomID,
+		Type:           models.MuteTypeStandard,
+		Reason:         opts.Reason,
 		UntilDate:      until,
 		CreatedDate:    time.Now(),
 	}
+	if opts.Shadow {
+		mutedUser.Type = models.MuteTypeShadow
+	}
 	if len(user.Username) > 0 {
 		mutedUser.Username = sql.NullString{
 			Valid:  true,
@@ -71,21 +218,32 @@ func (s *ChatService) MuteUser(
 		}
 	}
 	if len(user.IpAddress) > 0 {
+		ipAddress, err := NormalizeMuteIpAddress(user.IpAddress, opts.GroupIpv6)
+		if err != nil {
+			return nil, err
+		}
 		mutedUser.IpAddress = sql.NullString{
 			Valid:  true,
-			String: user.IpAddress,
+			String: s.IpHasher.HashIp(ipAddress),
 		}
 	}
 	if err := s.DB.Create(&mutedUser).Error; err != nil {
 		return nil, err
 	}
+	if err := s.incrementChatUser(organizationID, user.Username, "mutes_received"); err != nil {
+		return nil, err
+	}
+	s.invalidateIpMutes(organizationID)
 	return &mutedUser, nil
 
 }
 
+// UnmuteUser lifts the mutes on a username and/or IP address in an organization. The IP address is normalized the
+// same way it was when muted, and the mutes on any range containing a single address are lifted along with it.
 func (s *ChatService) UnmuteUser(
 	organizationID uint64,
 	user *ChatUserInfo,
+	groupIpv6 bool,
 ) error {
 
 	// If the user info is missing both fields
@@ -107,49 +265,288 @@ func (s *ChatService) UnmuteUser(
 		ors = ors.Or("username LIKE ?", user.Username)
 	}
 	if len(user.IpAddress) > 0 {
-		ors = ors.Or("ip_address LIKE ?", user.IpAddress)
+		ipAddress, err := NormalizeMuteIpAddress(user.IpAddress, groupIpv6)
+		if err != nil {
+			return err
+		}
+		ors = ors.Or("ip_address IN ?", s.IpHasher.StoredForms(ipAddress))
+		muteIDs, err := s.getIpMuteIDs(organizationID, user.IpAddress)
+		if err != nil {
+			return err
+		}
+		if len(muteIDs) > 0 {
+			ors = ors.Or("id IN ?", muteIDs)
+		}
 	}
 
 	// Update all of the muted users and mark as deleted
-	return query.
+	err := query.
 		Where(ors).
 		Update("deleted_date", time.Now()).
 		Error
+	if err != nil {
+		return err
+	}
+	s.invalidateIpMutes(organizationID)
+	return nil
 
 }
 
-func (s *ChatService) IsUserMuted(
-	organizationID uint64,
+// GetActiveMute gets the mute that applies to a user in a chat room, either a mute on the chat room itself or a mute
+// on the whole organization. If both standard and shadow mutes apply, the standard mute is returned.
+func (s *ChatService) GetActiveMute(
+	chatRoom *models.ChatRoom,
 	user *ChatUserInfo,
-) (bool, error) {
+) (*models.MutedUser, error) {
 
 	// If the user info is missing both fields
 	if len(user.Username) == 0 && len(user.IpAddress) == 0 {
-		return false, nil
+		return nil, nil
+	}
+
+	// Find the mutes on the IP address, which can be single addresses or whole ranges
+	mutes, err := s.getIpMutes(chatRoom, user.IpAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the mutes on the username. Mutes with an until date only apply until that date has passed
+	if len(user.Username) > 0 {
+		var usernameMutes []*models.MutedUser
+		err := s.DB.
+			Where("deleted_date IS NULL").
+			Where("until_date IS NULL OR until_date > ?", time.Now()).
+			Where("organization_id = ?", chatRoom.OrganizationID).
+			Where("chat_room_id IS NULL OR chat_room_id = ?", chatRoom.ID).
+			Where("username LIKE ?", user.Username).
+			Find(&usernameMutes).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		mutes = append(mutes, usernameMutes...)
+	}
+
+	// Prefer a standard mute over a shadow mute
+	var active *models.MutedUser
+	for _, mute := range mutes {
+		if active == nil || (active.IsShadow() && !mute.IsShadow()) {
+			active = mute
+		}
+	}
+	return active, nil
+
+}
+
+// IsUserMuted checks if a user is muted in a chat room, either by a mute on the chat room itself or by a mute
+// on the whole organization
+func (s *ChatService) IsUserMuted(
+	chatRoom *models.ChatRoom,
+	user *ChatUserInfo,
+) (bool, error) {
+	mute, err := s.GetActiveMute(chatRoom, user)
+	if err != nil {
+		return false, err
 	}
+	return mute != nil, nil
+}
+
+// MuteFilter narrows down the mutes returned when listing the muted users in an organization
+type MuteFilter struct {
+	OrganizationID uint64
+	ChatRoomID     uint64
+	Status         string
+	Search         string
+	Offset         int
+	Limit          int
+}
+
+const (
+	// MuteStatusActive filters for mutes that are still in effect
+	MuteStatusActive = "active"
+
+	// MuteStatusExpired filters for mutes whose until date has passed
+	MuteStatusExpired = "expired"
+)
+
+// ListMutes gets the mutes in an organization matching a filter, newest first, along with the total number
+// of mutes that match the filter regardless of paging
+func (s *ChatService) ListMutes(filter *MuteFilter) ([]*models.MutedUser, int64, error) {
 
 	// Construct the query
+	now := time.Now()
 	query := s.DB.
+		Model(&models.MutedUser{}).
 		Where("deleted_date IS NULL").
-		Where("until_date IS NULL OR until_date < ?", time.Now()).
-		Where("organization_id = ?", organizationID)
+		Where("organization_id = ?", filter.OrganizationID)
 
-	// Add the username and/or IP address
-	if len(user.Username) > 0 {
-		query = query.Where("username LIKE ?", user.Username)
+	// Filter by the chat room the mute applies to
+	if filter.ChatRoomID > 0 {
+		query = query.Where("chat_room_id = ?", filter.ChatRoomID)
 	}
-	if len(user.IpAddress) > 0 {
-		query = query.Where("ip_address LIKE ?", user.IpAddress)
+
+	// Filter by the status of the mute
+	switch filter.Status {
+	case MuteStatusActive:
+		query = query.Where("until_date IS NULL OR until_date > ?", now)
+	case MuteStatusExpired:
+		query = query.Where("until_date <= ?", now)
+	}
+
+	// Search the username and IP address
+	if len(filter.Search) > 0 {
+		search := "%" + filter.Search + "%"
+		query = query.Where(
+			"username LIKE ? OR ip_address LIKE ? OR ip_address IN ?",
+			search,
+			search,
+			s.IpHasher.StoredForms(filter.Search),
+		)
+	}
+
+	// Count all of the matching mutes
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
+	// Get the page of mutes
+	var mutes []*models.MutedUser
+	err := query.
+		Order("created_date DESC").
+		Order("id DESC").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&mutes).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return mutes, total, nil
+
+}
+
+// GetMuteByID gets the mute with the provided ID
+func (s *ChatService) GetMuteByID(id uint64) (*models.MutedUser, error) {
 	var mutedUser models.MutedUser
-	if err := query.First(&mutedUser).Error; err != nil {
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&mutedUser).
+		Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return false, nil
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mutedUser, nil
+}
+
+// DeleteMute lifts a single mute
+func (s *ChatService) DeleteMute(mutedUser *models.MutedUser) error {
+	err := s.DB.
+		Model(mutedUser).
+		Update("deleted_date", time.Now()).
+		Error
+	if err != nil {
+		return err
+	}
+	s.invalidateIpMutes(mutedUser.OrganizationID)
+	return nil
+}
+
+// UpdateMute changes the until date and reason of a mute. A nil until date makes the mute permanent. If the
+// new until date is in the future, an expired mute goes back into effect.
+func (s *ChatService) UpdateMute(
+	mutedUser *models.MutedUser,
+	untilDate *time.Time,
+	reason string,
+) error {
+
+	// Update the fields on the mute
+	mutedUser.Reason = reason
+	mutedUser.UntilDate = sql.NullTime{}
+	if untilDate != nil {
+		mutedUser.UntilDate = sql.NullTime{
+			Valid: true,
+			Time:  *untilDate,
+		}
+	}
+	if mutedUser.IsActive() {
+		mutedUser.ExpiredDate = sql.NullTime{}
+	}
+
+	// Save the changes
+	err := s.DB.
+		Model(mutedUser).
+		Select("reason", "until_date", "expired_date").
+		Updates(mutedUser).
+		Error
+	if err != nil {
+		return err
+	}
+	s.invalidateIpMutes(mutedUser.OrganizationID)
+	return nil
+
+}
+
+// ExpireMutes marks all of the mutes whose until date has passed as expired. The mutes that were newly marked
+// are returned.
+func (s *ChatService) ExpireMutes() ([]*models.MutedUser, error) {
+
+	// Find all of the mutes that have expired but haven't been marked yet
+	now := time.Now()
+	var mutes []*models.MutedUser
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("expired_date IS NULL").
+		Where("until_date <= ?", now).
+		Find(&mutes).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	if len(mutes) == 0 {
+		return mutes, nil
+	}
+
+	// Mark them all as expired
+	ids := make([]uint64, len(mutes))
+	for i, mute := range mutes {
+		ids[i] = mute.ID
+		mute.ExpiredDate = sql.NullTime{
+			Valid: true,
+			Time:  now,
 		}
-		return false, err
 	}
-	return true, nil
+	err = s.DB.
+		Model(&models.MutedUser{}).
+		Where("id IN ?", ids).
+		Update("expired_date", now).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return mutes, nil
+
+}
+
+// SerializeMutedUser converts a mute to the map sent to moderators
+func SerializeMutedUser(mutedUser *models.MutedUser) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              mutedUser.ID,
+		"organization_id": mutedUser.OrganizationID,
+		"chat_room_id":    utils.FlattenNullInt64(mutedUser.ChatRoomID),
+		"type":            mutedUser.Type,
+		"username":        utils.FlattenNullString(mutedUser.Username),
+		"ip_address":      utils.FlattenNullString(mutedUser.IpAddress),
+		"reason":          mutedUser.Reason,
+		"until_date":      utils.FlattenNullTimeSec(mutedUser.UntilDate),
+		"expired_date":    utils.FlattenNullTimeSec(mutedUser.ExpiredDate),
+		"created_date":    mutedUser.CreatedDate.UTC().Unix(),
+		"active":          mutedUser.IsActive(),
+	}
 }
 
 // GetBannedWords gets all of the banned words for an organization. The slice returned also includes all of the
@@ -189,36 +586,506 @@ func (s *ChatService) messageContainsBannedWord(message string, word string) boo
 
 }
 
+const (
+	// VerdictAllowed means the message can be sent
+	VerdictAllowed = "allowed"
+
+	// VerdictMuted means the sender is muted
+	VerdictMuted = "muted"
+
+	// VerdictShadowMuted means the sender is shadow muted, so the message is only shown back to them
+	VerdictShadowMuted = "shadow_muted"
+
+	// VerdictBannedWord means the message contains a banned word
+	VerdictBannedWord = "banned_word"
+
+	// VerdictSpam means the message broke one of the organization's spam rules
+	VerdictSpam = "spam"
+
+	// VerdictLink means the message has a link that the organization's link policy doesn't allow
+	VerdictLink = "link"
+
+	// VerdictImpersonation means the sender's username looks like a name that is protected in the organization
+	VerdictImpersonation = "impersonation"
+
+	// VerdictFollowersOnly means the chat room is followers-only, and the sender hasn't followed for long enough
+	VerdictFollowersOnly = "followers_only"
+
+	// VerdictNewChatterWait means the sender was first seen too recently to chat in the room
+	VerdictNewChatterWait = "new_chatter_wait"
+
+	// VerdictNewChatterLink means the sender is a new chatter, who can't post links in the room
+	VerdictNewChatterLink = "new_chatter_link"
+
+	// VerdictHeld means the message is held for review by a moderator before it can be broadcast
+	VerdictHeld = "held"
+)
+
+const (
+	// HoldReasonSpam is the hold reason of a message held by a spam rule
+	HoldReasonSpam = "spam"
+
+	// HoldReasonLink is the hold reason of a message held by the link policy of the organization
+	HoldReasonLink = "link"
+)
+
+// MessageVerdict is the outcome of checking whether a message can be sent. HoldReason is the hold mode of the
+// chat room that caught a held message, or one of the other hold reasons. ChatUser is the sender's profile, if
+// they have one.
+type MessageVerdict struct {
+	Allowed       bool
+	Reason        string
+	BannedWord    *models.BannedWord
+	SpamRule      *models.SpamRule
+	LinkDomain    string
+	ChatUser      *models.ChatUser
+	Impersonation *ImpersonationMatch
+	Mute          *models.MutedUser
+	HoldReason    string
+}
+
 // CanSendMessage determines if a given message can be sent from a user to a chatroom
 func (s *ChatService) CanSendMessage(
 	chatRoom *models.ChatRoom,
 	user *ChatUserInfo,
 	message string,
-) (bool, *models.BannedWord, error) {
+) (*MessageVerdict, error) {
 
 	// Check if the user is banned
-	muted, err := s.IsUserMuted(chatRoom.OrganizationID, user)
+	mute, err := s.GetActiveMute(chatRoom, user)
+	if err != nil {
+		return nil, err
+	}
+	if mute != nil {
+		if mute.IsShadow() {
+			return &MessageVerdict{Reason: VerdictShadowMuted, Mute: mute}, nil
+		}
+		return &MessageVerdict{Reason: VerdictMuted, Mute: mute}, nil
+	}
+
+	// Get the profile of the sender, noting that they were seen. A profile that belongs to someone else says
+	// nothing about the sender, who is treated as brand new.
+	chatUser, err := s.TouchChatUser(chatRoom.OrganizationID, user)
+	if err != nil {
+		return nil, err
+	}
+	if chatUser != nil && !s.isChatUserOwner(chatUser, user) {
+		chatUser = nil
+	}
+
+	// Check the message itself, attaching the profile to the verdict
+	verdict, err := s.checkMessage(chatRoom, chatUser, user, message)
+	if err != nil {
+		return nil, err
+	}
+	verdict.ChatUser = chatUser
+	return verdict, nil
+
+}
+
+// checkMessage checks a message against the restrictions of the chat room and the automod rules of the
+// organization
+func (s *ChatService) checkMessage(
+	chatRoom *models.ChatRoom,
+	chatUser *models.ChatUser,
+	user *ChatUserInfo,
+	message string,
+) (*MessageVerdict, error) {
+
+	// Check the restrictions the chat room puts on chatters
+	if reason := s.checkChatterRestrictions(chatRoom, chatUser, user, message); len(reason) > 0 {
+		return &MessageVerdict{Reason: reason}, nil
+	}
+
+	// Check if the username looks like a protected name. Lookalikes are rejected, while usernames that contain a
+	// protected name are flagged
+	impersonation, err := s.CheckImpersonation(chatRoom.OrganizationID, user.Username, user.Account)
 	if err != nil {
-		return false, nil, err
+		return nil, err
 	}
-	if muted {
-		return false, nil, nil
+	if impersonation != nil && impersonation.Exact {
+		return &MessageVerdict{
+			Reason:        VerdictImpersonation,
+			Impersonation: impersonation,
+		}, nil
 	}
 
 	// Check for all the banned words
 	bannedWords, err := s.GetBannedWords(chatRoom.OrganizationID)
 	if err != nil {
-		return false, nil, err
+		return nil, err
 	}
 
-	// Loop through the banned words
+	// Loop through the banned words. Flagged words don't block the message, but are remembered in case the
+	// chat room holds flagged messages
+	var flaggedWord *models.BannedWord
 	for _, bw := range bannedWords {
-		if s.messageContainsBannedWord(message, bw.Word) {
-			return false, bw, nil
+		if !s.messageContainsBannedWord(message, bw.Word) {
+			continue
+		}
+		if bw.FlagOnly {
+			if flaggedWord == nil {
+				flaggedWord = bw
+			}
+			continue
+		}
+		return &MessageVerdict{
+			Reason:     VerdictBannedWord,
+			BannedWord: bw,
+		}, nil
+	}
+
+	// Check the message against the spam rules
+	spamRule, err := s.checkSpamRules(chatRoom, user, message)
+	if err != nil {
+		return nil, err
+	}
+	if spamRule != nil {
+		if spamRule.Action == models.SpamActionHold {
+			return &MessageVerdict{
+				Reason:     VerdictHeld,
+				SpamRule:   spamRule,
+				HoldReason: HoldReasonSpam,
+			}, nil
+		}
+		return &MessageVerdict{
+			Reason:   VerdictSpam,
+			SpamRule: spamRule,
+		}, nil
+	}
+
+	// Check the links in the message against the link policy
+	linkAction, linkDomain, err := s.checkLinkPolicy(chatRoom, message)
+	if err != nil {
+		return nil, err
+	}
+	switch linkAction {
+	case models.LinkPolicyBlock:
+		return &MessageVerdict{
+			Reason:     VerdictLink,
+			LinkDomain: linkDomain,
+		}, nil
+	case models.LinkPolicyHold:
+		return &MessageVerdict{
+			Reason:     VerdictHeld,
+			LinkDomain: linkDomain,
+			HoldReason: HoldReasonLink,
+		}, nil
+	}
+
+	// Check if the chat room holds the message for review
+	holdReason := getHoldReason(chatRoom, chatUser, flaggedWord != nil || impersonation != nil)
+	if len(holdReason) > 0 {
+		return &MessageVerdict{
+			Reason:        VerdictHeld,
+			BannedWord:    flaggedWord,
+			Impersonation: impersonation,
+			HoldReason:    holdReason,
+		}, nil
+	}
+
+	// The message looks good, though moderators still see anything that was flagged
+	return &MessageVerdict{
+		Allowed:       true,
+		Reason:        VerdictAllowed,
+		BannedWord:    flaggedWord,
+		Impersonation: impersonation,
+	}, nil
+
+}
+
+// checkChatterRestrictions checks a message against the restrictions a chat room puts on its chatters, returning
+// the verdict reason if the message breaks one. Chatters without a profile are treated as brand new.
+func (s *ChatService) checkChatterRestrictions(
+	chatRoom *models.ChatRoom,
+	chatUser *models.ChatUser,
+	user *ChatUserInfo,
+	message string,
+) string {
+	now := time.Now()
+
+	// Followers-only rooms need the chatter to have followed for long enough
+	if chatRoom.FollowersOnlyMinutes.Valid {
+		minimum := time.Minute * time.Duration(chatRoom.FollowersOnlyMinutes.Int64)
+		if chatUser == nil || !s.isFollower(chatUser, user) || !chatUser.IsFollowing(minimum, now) {
+			return VerdictFollowersOnly
+		}
+	}
+
+	// New chatters may need to wait before chatting
+	if chatRoom.NewChatterWaitMinutes > 0 {
+		wait := time.Minute * time.Duration(chatRoom.NewChatterWaitMinutes)
+		if chatUser == nil || now.Sub(chatUser.FirstSeenDate) < wait {
+			return VerdictNewChatterWait
+		}
+	}
+
+	// New chatters may not be allowed to post links
+	if chatRoom.BlockNewChatterLinks && (chatUser == nil || chatUser.IsNewChatter(now)) {
+		if len(utils.FindLinkDomains(message)) > 0 {
+			return VerdictNewChatterLink
 		}
 	}
 
-	// The message looks good
-	return true, nil, nil
+	return ""
+}
+
+// getHoldReason gets the hold mode that catches a message in a chat room, or an empty string if the message
+// doesn't need to be held. First-time chatters are those who haven't had a message sent yet.
+func getHoldReason(chatRoom *models.ChatRoom, chatUser *models.ChatUser, flagged bool) string {
+	switch chatRoom.HoldMode {
+	case models.HoldModeAll:
+		return models.HoldModeAll
+	case models.HoldModeFlagged:
+		if flagged {
+			return models.HoldModeFlagged
+		}
+	case models.HoldModeFirstTime:
+		if chatUser == nil || chatUser.MessageCount == 0 {
+			return models.HoldModeFirstTime
+		}
+	}
+	return ""
+}
+
+// ChatPin is a message pinned to the top of a chat room
+type ChatPin struct {
+	MessageID string
+	Username  string
+	PhotoUrl  string
+	Message   string
+}
+
+// pinnedColumns are the columns on a chat room that make up its pinned message
+var pinnedColumns = []string{
+	"pinned_message_id",
+	"pinned_username",
+	"pinned_photo_url",
+	"pinned_message",
+	"pinned_until_date",
+}
 
+// PinMessage pins a message to the top of a chat room, replacing any message that was already pinned
+func (s *ChatService) PinMessage(
+	chatRoom *models.ChatRoom,
+	pin *ChatPin,
+	untilDate *time.Time,
+) error {
+
+	// Update the pin fields on the chat room
+	chatRoom.PinnedMessageID = sql.NullString{Valid: true, String: pin.MessageID}
+	chatRoom.PinnedUsername = sql.NullString{Valid: true, String: pin.Username}
+	chatRoom.PinnedPhotoUrl = sql.NullString{Valid: true, String: pin.PhotoUrl}
+	chatRoom.PinnedMessage = sql.NullString{Valid: true, String: pin.Message}
+	chatRoom.PinnedUntilDate = sql.NullTime{}
+	if untilDate != nil {
+		chatRoom.PinnedUntilDate = sql.NullTime{
+			Valid: true,
+			Time:  *untilDate,
+		}
+	}
+
+	// Save the pin fields
+	return s.DB.
+		Model(chatRoom).
+		Select(pinnedColumns).
+		Updates(chatRoom).
+		Error
+
+}
+
+// UnpinMessage removes the pinned message from a chat room
+func (s *ChatService) UnpinMessage(chatRoom *models.ChatRoom) error {
+
+	// Clear all of the pin fields
+	chatRoom.PinnedMessageID = sql.NullString{}
+	chatRoom.PinnedUsername = sql.NullString{}
+	chatRoom.PinnedPhotoUrl = sql.NullString{}
+	chatRoom.PinnedMessage = sql.NullString{}
+	chatRoom.PinnedUntilDate = sql.NullTime{}
+
+	// Save the pin fields
+	return s.DB.
+		Model(chatRoom).
+		Select(pinnedColumns).
+		Updates(chatRoom).
+		Error
+
+}
+
+// SaveMessage adds a message to the chat history of a chat room
+func (s *ChatService) SaveMessage(
+	chatRoom *models.ChatRoom,
+	msgID string,
+	user *ChatUserInfo,
+	msg *ChatMsg,
+) (*models.ChatMessage, error) {
+	chatMessage := models.ChatMessage{
+		OrganizationID: chatRoom.OrganizationID,
+		ChatRoomID:     chatRoom.ID,
+		MessageID:      msgID,
+		Username:       user.Username,
+		PhotoUrl:       msg.User.PhotoUrl,
+		IpAddress:      s.IpHasher.HashIp(user.IpAddress),
+		IpNetwork:      s.IpHasher.NetworkForm(user.IpAddress),
+		Message:        msg.Message,
+		CreatedDate:    time.Now(),
+	}
+	if user.Account != nil {
+		chatMessage.AccountID = sql.NullInt64{Valid: true, Int64: int64(user.Account.ID)}
+	}
+	if msg.Reply != nil {
+		chatMessage.ReplyToMessageID = sql.NullString{Valid: true, String: msg.Reply.ID}
+	}
+	if err := s.DB.Create(&chatMessage).Error; err != nil {
+		return nil, err
+	}
+	if err := s.incrementChatUser(chatRoom.OrganizationID, user.Username, "message_count"); err != nil {
+		return nil, err
+	}
+	return &chatMessage, nil
+}
+
+// GetMessage gets a message from the chat history of a chat room, or nil if it was never saved, or was deleted or
+// cleared since
+func (s *ChatService) GetMessage(chatRoom *models.ChatRoom, msgID string) (*models.ChatMessage, error) {
+	var chatMessage models.ChatMessage
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("cleared_date IS NULL").
+		Where("chat_room_id = ?", chatRoom.ID).
+		Where("message_id = ?", msgID).
+		First(&chatMessage).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &chatMessage, nil
+}
+
+// EditMessage replaces the text of a message in the chat history of a chat room, marking it as edited
+func (s *ChatService) EditMessage(chatRoom *models.ChatRoom, msgID string, message string) error {
+	return s.DB.
+		Model(&models.ChatMessage{}).
+		Where("deleted_date IS NULL").
+		Where("chat_room_id = ?", chatRoom.ID).
+		Where("message_id = ?", msgID).
+		Updates(map[string]interface{}{
+			"message":     message,
+			"edited_date": time.Now(),
+		}).
+		Error
+}
+
+// DeleteMessage removes a message from the chat history of a chat room
+func (s *ChatService) DeleteMessage(chatRoom *models.ChatRoom, msgID string) error {
+	return s.DB.
+		Model(&models.ChatMessage{}).
+		Where("deleted_date IS NULL").
+		Where("chat_room_id = ?", chatRoom.ID).
+		Where("message_id = ?", msgID).
+		Update("deleted_date", time.Now()).
+		Error
+}
+
+// ValidatePurge makes sure the messages of a user can be found to purge them. Once IP addresses are hashed, the
+// only ranges that can still be matched are IPv6 /64 networks.
+func (s *ChatService) ValidatePurge(user *ChatUserInfo) error {
+	ipAddress := user.IpAddress
+	if strings.Contains(ipAddress, "/") && s.IpHasher.Enabled() && s.IpHasher.HashIp(ipAddress) == ipAddress {
+		return errors.New("only IPv6 /64 ranges can be purged while IP addresses are hashed")
+	}
+	return nil
+}
+
+// DeleteUserMessages removes all of the messages sent by a user, matched by either username or IP address, from
+// the chat history of a chat room. The IDs of the removed messages are returned.
+func (s *ChatService) DeleteUserMessages(chatRoom *models.ChatRoom, user *ChatUserInfo) ([]string, error) {
+
+	// If the user info is missing both fields
+	if len(user.Username) == 0 && len(user.IpAddress) == 0 {
+		return nil, nil
+	}
+
+	// Make sure the IP address can be matched
+	if err := s.ValidatePurge(user); err != nil {
+		return nil, err
+	}
+
+	// Create the ors
+	ors := s.DB
+
+	// Add the username and/or IP address. An IP range can't be matched in the query, so every message with an
+	// address is loaded and checked against the range afterwards. IPv6 /64 ranges are also matched on the
+	// network of each message, which still works once addresses are hashed.
+	isRange := strings.Contains(user.IpAddress, "/")
+	networkForms := map[string]bool{}
+	if len(user.Username) > 0 {
+		ors = ors.Or(usernameQuery("username"), user.Username)
+	}
+	if isRange {
+		ors = ors.Or("ip_address <> ''")
+		for _, form := range s.IpHasher.StoredForms(user.IpAddress) {
+			networkForms[form] = true
+		}
+	} else if len(user.IpAddress) > 0 {
+		ors = ors.Or("ip_address IN ?", s.IpHasher.StoredForms(user.IpAddress))
+	}
+
+	// Find all the messages
+	var messages []*models.ChatMessage
+	err := s.DB.
+		Select("message_id", "username", "ip_address", "ip_network").
+		Where("deleted_date IS NULL").
+		Where("chat_room_id = ?", chatRoom.ID).
+		Where(ors).
+		Find(&messages).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the IDs of the messages, checking them against the IP range if needed
+	msgIDs := []string{}
+	for _, message := range messages {
+		if isRange {
+			sentByUsername := len(user.Username) > 0 && strings.EqualFold(message.Username, user.Username)
+			sentFromNetwork := len(message.IpNetwork) > 0 && networkForms[message.IpNetwork]
+			if !sentByUsername && !sentFromNetwork && !utils.IpInRange(user.IpAddress, message.IpAddress) {
+				continue
+			}
+		}
+		msgIDs = append(msgIDs, message.MessageID)
+	}
+	if len(msgIDs) == 0 {
+		return msgIDs, nil
+	}
+
+	// Mark all of the messages as deleted
+	err = s.DB.
+		Model(&models.ChatMessage{}).
+		Where("deleted_date IS NULL").
+		Where("chat_room_id = ?", chatRoom.ID).
+		Where("message_id IN ?", msgIDs).
+		Update("deleted_date", time.Now()).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return msgIDs, nil
+
+}
+
+// ClearMessages marks every message in the chat history of a chat room as cleared
+func (s *ChatService) ClearMessages(chatRoom *models.ChatRoom) error {
+	return s.DB.
+		Model(&models.ChatMessage{}).
+		Where("deleted_date IS NULL").
+		Where("cleared_date IS NULL").
+		Where("chat_room_id = ?", chatRoom.ID).
+		Update("cleared_date", time.Now()).
+		Error
 }
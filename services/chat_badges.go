@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+)
+
+// badgeColorPattern matches the hex colors allowed as badge backgrounds, like #fff or #1e90ff
+var badgeColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// SerializeBadge converts a badge into a map
+func SerializeBadge(badge *models.Badge) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               badge.ID,
+		"name":             badge.Name,
+		"background_color": badge.BackgroundColor,
+		"image":            badge.Image,
+		"created_date":     badge.CreatedDate.UTC().Unix(),
+	}
+}
+
+// GetBadges gets all of the badges of an organization
+func (s *ChatService) GetBadges(organizationID uint64) ([]*models.Badge, error) {
+	var badges []*models.Badge
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Order("id ASC").
+		Find(&badges).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return badges, nil
+}
+
+// GetBadgeByID gets the badge with the provided ID
+func (s *ChatService) GetBadgeByID(id uint64) (*models.Badge, error) {
+	var badge models.Badge
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&badge).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &badge, nil
+}
+
+// ValidateBadge makes sure a badge has a name and a valid background color
+func ValidateBadge(badge *models.Badge) error {
+	if len(strings.TrimSpace(badge.Name)) == 0 {
+		return errors.New("badge name is required")
+	}
+	if len(badge.BackgroundColor) > 0 && !badgeColorPattern.MatchString(badge.BackgroundColor) {
+		return errors.New("background color must be a hex color")
+	}
+	return nil
+}
+
+// SaveBadge creates or updates a badge, after making sure its settings are valid
+func (s *ChatService) SaveBadge(badge *models.Badge) error {
+	badge.Name = strings.TrimSpace(badge.Name)
+	if err := ValidateBadge(badge); err != nil {
+		return err
+	}
+	if badge.ID == 0 {
+		badge.CreatedDate = time.Now()
+		return s.DB.Create(badge).Error
+	}
+	return s.DB.
+		Model(badge).
+		Select("name", "background_color", "image").
+		Updates(badge).
+		Error
+}
+
+// DeleteBadge removes a badge, along with everyone's assignments of it
+func (s *ChatService) DeleteBadge(badge *models.Badge) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.
+			Model(&models.BadgeAssignment{}).
+			Where("deleted_date IS NULL").
+			Where("badge_id = ?", badge.ID).
+			Update("deleted_date", now).
+			Error
+		if err != nil {
+			return err
+		}
+		return tx.
+			Model(badge).
+			Update("deleted_date", now).
+			Error
+	})
+}
+
+//====================================================================================================
+// Badge assignments
+//====================================================================================================
+
+// SerializeBadgeAssignment converts a badge assignment into a map
+func SerializeBadgeAssignment(assignment *models.BadgeAssignment) map[string]interface{} {
+	var accountEmail interface{}
+	if assignment.Account != nil {
+		accountEmail = assignment.Account.Email
+	}
+	var chatRoomIdentifier interface{}
+	if assignment.ChatRoom != nil {
+		chatRoomIdentifier = assignment.ChatRoom.Identifier
+	}
+	return map[string]interface{}{
+		"id":                   assignment.ID,
+		"badge_id":             assignment.BadgeID,
+		"username":             utils.FlattenNullString(assignment.Username),
+		"account_email":        accountEmail,
+		"chat_room_identifier": chatRoomIdentifier,
+		"role":                 assignment.Role,
+		"created_date":         assignment.CreatedDate.UTC().Unix(),
+	}
+}
+
+// GetBadgeAssignments gets all of the badge assignments in an organization
+func (s *ChatService) GetBadgeAssignments(organizationID uint64) ([]*models.BadgeAssignment, error) {
+	var assignments []*models.BadgeAssignment
+	err := s.DB.
+		Preload("Account").
+		Preload("ChatRoom").
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Order("id ASC").
+		Find(&assignments).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// GetBadgeAssignmentByID gets the badge assignment with the provided ID
+func (s *ChatService) GetBadgeAssignmentByID(id uint64) (*models.BadgeAssignment, error) {
+	var assignment models.BadgeAssignment
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&assignment).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// AssignBadge gives a badge to a chatter. The assignment needs either a username or an account to identify the
+// chatter by.
+func (s *ChatService) AssignBadge(assignment *models.BadgeAssignment) error {
+
+	// Make sure the assignment identifies someone
+	if assignment.Username.Valid {
+		assignment.Username.String = chatUserKey(assignment.Username.String)
+		assignment.Username.Valid = len(assignment.Username.String) > 0
+	}
+	if !assignment.Username.Valid && !assignment.AccountID.Valid {
+		return errors.New("a username or an account is required")
+	}
+	if !models.IsValidChatRole(assignment.Role) {
+		return errors.New("invalid role")
+	}
+
+	// Anyone can chat under a username, so role badges have to be assigned to an account
+	if assignment.Role != models.ChatRoleNone && !assignment.AccountID.Valid {
+		return errors.New("role badges can only be assigned to accounts")
+	}
+
+	// Create the assignment
+	assignment.CreatedDate = time.Now()
+	return s.DB.Create(assignment).Error
+
+}
+
+// DeleteBadgeAssignment takes a badge away from a chatter
+func (s *ChatService) DeleteBadgeAssignment(assignment *models.BadgeAssignment) error {
+	return s.DB.
+		Model(assignment).
+		Update("deleted_date", time.Now()).
+		Error
+}
+
+// GetMessageBadges gets the badges shown next to a chatter's messages in a chat room. Assignments to the chatter's
+// username and to the account they are signed in with both count, and each badge is only included once. Role
+// badges only count when assigned to the account, so they can't be shown by typing someone else's username.
+func (s *ChatService) GetMessageBadges(
+	chatRoom *models.ChatRoom,
+	user *ChatUserInfo,
+) ([]*models.BadgeAssignment, error) {
+
+	// Build the conditions that identify the chatter
+	identity := s.DB
+	hasIdentity := false
+	if username := chatUserKey(user.Username); len(username) > 0 {
+		identity = identity.Or("username = ?", username)
+		hasIdentity = true
+	}
+	if user.Account != nil {
+		identity = identity.Or("account_id = ?", user.Account.ID)
+		hasIdentity = true
+	}
+	if !hasIdentity {
+		return []*models.BadgeAssignment{}, nil
+	}
+
+	// Get the assignments in the organization, or in the chat room only
+	var assignments []*models.BadgeAssignment
+	err := s.DB.
+		Preload("Badge").
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", chatRoom.OrganizationID).
+		Where("chat_room_id IS NULL OR chat_room_id = ?", chatRoom.ID).
+		Where(identity).
+		Order("id ASC").
+		Find(&assignments).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep one assignment of each badge, skipping badges that were deleted
+	badges := []*models.BadgeAssignment{}
+	seen := map[uint64]bool{}
+	for _, assignment := range assignments {
+		if assignment.Badge == nil || assignment.Badge.DeletedDate.Valid || seen[assignment.BadgeID] {
+			continue
+		}
+		if assignment.Role != models.ChatRoleNone &&
+			(user.Account == nil || uint64(assignment.AccountID.Int64) != user.Account.ID) {
+			continue
+		}
+		seen[assignment.BadgeID] = true
+		badges = append(badges, assignment)
+	}
+	return badges, nil
+
+}
+
+// SerializeMessageBadge converts a badge shown next to a message into a map
+func SerializeMessageBadge(assignment *models.BadgeAssignment) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               assignment.Badge.ID,
+		"name":             assignment.Badge.Name,
+		"background_color": assignment.Badge.BackgroundColor,
+		"image":            assignment.Badge.Image,
+		"role":             assignment.Role,
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+)
+
+// emoteCodePattern matches the codes allowed for emotes, which are typed between colons in messages
+var emoteCodePattern = regexp.MustCompile(`^[A-Za-z0-9_]{2,32}$`)
+
+// SerializeEmote converts an emote into a map
+func SerializeEmote(emote *models.Emote) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           emote.ID,
+		"code":         emote.Code,
+		"image":        emote.Image,
+		"created_date": emote.CreatedDate.UTC().Unix(),
+	}
+}
+
+// GetEmotes gets all of the emotes of an organization
+func (s *ChatService) GetEmotes(organizationID uint64) ([]*models.Emote, error) {
+	var emotes []*models.Emote
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Order("code ASC").
+		Find(&emotes).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return emotes, nil
+}
+
+// GetEmoteByID gets the emote with the provided ID
+func (s *ChatService) GetEmoteByID(id uint64) (*models.Emote, error) {
+	var emote models.Emote
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&emote).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &emote, nil
+}
+
+// ValidateEmoteCode makes sure an emote code can be typed in a message
+func ValidateEmoteCode(code string) error {
+	if !emoteCodePattern.MatchString(code) {
+		return errors.New("emote code must be 2 to 32 letters, numbers or underscores")
+	}
+	return nil
+}
+
+// AddEmote creates an emote in an organization, making sure its code isn't already taken
+func (s *ChatService) AddEmote(organizationID uint64, code string, image string) (*models.Emote, error) {
+
+	// Check the code
+	if err := ValidateEmoteCode(code); err != nil {
+		return nil, err
+	}
+	var count int64
+	err := s.DB.
+		Model(&models.Emote{}).
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Where("code = ?", code).
+		Count(&count).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("emote code is already taken")
+	}
+
+	// Create the emote
+	emote := models.Emote{
+		OrganizationID: organizationID,
+		Code:           code,
+		Image:          image,
+		CreatedDate:    time.Now(),
+	}
+	if err := s.DB.Create(&emote).Error; err != nil {
+		return nil, err
+	}
+	return &emote, nil
+
+}
+
+// DeleteEmote removes an emote
+func (s *ChatService) DeleteEmote(emote *models.Emote) error {
+	return s.DB.
+		Model(emote).
+		Update("deleted_date", time.Now()).
+		Error
+}
+
+//====================================================================================================
+// Message fragments
+//====================================================================================================
+
+// MessageFragment is a piece of a chat message, as sent to clients so they all render messages the same way.
+// Emote is set for emote fragments.
+type MessageFragment struct {
+	utils.MessageFragment
+	Emote *models.Emote
+}
+
+// SerializeMessageFragment converts a message fragment into a map
+func SerializeMessageFragment(fragment *MessageFragment) map[string]interface{} {
+	fragmentSer := map[string]interface{}{
+		"type": fragment.Type,
+		"text": fragment.Text,
+	}
+	switch fragment.Type {
+	case utils.FragmentEmote:
+		fragmentSer["emote"] = map[string]interface{}{
+			"id":    fragment.Emote.ID,
+			"code":  fragment.Emote.Code,
+			"image": fragment.Emote.Image,
+		}
+	case utils.FragmentMention:
+		fragmentSer["username"] = fragment.Value
+	case utils.FragmentLink:
+		fragmentSer["url"] = fragment.Value
+	}
+	return fragmentSer
+}
+
+// GetMessageFragments splits a message into fragments of text, emotes, mentions and links, recognizing the emotes
+// of the organization
+func (s *ChatService) GetMessageFragments(organizationID uint64, message string) ([]*MessageFragment, error) {
+
+	// Get the emotes of the organization
+	emotes, err := s.GetEmotes(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	emotesByCode := map[string]*models.Emote{}
+	for _, emote := range emotes {
+		emotesByCode[emote.Code] = emote
+	}
+
+	// Split up the message
+	tokens := utils.TokenizeMessage(message, func(code string) bool {
+		return emotesByCode[code] != nil
+	})
+	fragments := make([]*MessageFragment, len(tokens))
+	for i, token := range tokens {
+		fragment := &MessageFragment{MessageFragment: token}
+		if token.Type == utils.FragmentEmote {
+			fragment.Emote = emotesByCode[token.Value]
+		}
+		fragments[i] = fragment
+	}
+	return fragments, nil
+
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+)
+
+// protectedNamesCacheTTL is how long the protected names of an organization are kept in memory before being
+// reloaded, so display names and members changed elsewhere are picked up
+const protectedNamesCacheTTL = 30 * time.Second
+
+// minContainedSkeleton is the shortest protected name that is flagged when it appears inside a longer username.
+// Shorter names show up inside ordinary usernames too often to be worth flagging.
+const minContainedSkeleton = 4
+
+// protectedName is a name that chatters can't pose as. AccountID is the account the name belongs to, which is
+// allowed to use it, or zero for a reserved name. Skeleton is the confusable skeleton the name is compared by.
+type protectedName struct {
+	Name      string
+	AccountID uint64
+	Skeleton  string
+}
+
+// protectedNameCache holds the protected names of an organization, so they aren't loaded for every message
+type protectedNameCache struct {
+	names      []protectedName
+	loadedDate time.Time
+}
+
+// ImpersonationMatch is a protected name that a username looks like. Exact matches look the same as the name,
+// while other matches only contain it.
+type ImpersonationMatch struct {
+	Name  string
+	Exact bool
+}
+
+// SerializeReservedName converts a reserved name into a map
+func SerializeReservedName(reservedName *models.ReservedName) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           reservedName.ID,
+		"name":         reservedName.Name,
+		"created_date": reservedName.CreatedDate.UTC().Unix(),
+	}
+}
+
+// GetReservedNames gets the reserved names of an organization
+func (s *ChatService) GetReservedNames(organizationID uint64) ([]*models.ReservedName, error) {
+	var reservedNames []*models.ReservedName
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Order("name ASC").
+		Find(&reservedNames).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return reservedNames, nil
+}
+
+// GetReservedNameByID gets the reserved name with the provided ID
+func (s *ChatService) GetReservedNameByID(id uint64) (*models.ReservedName, error) {
+	var reservedName models.ReservedName
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&reservedName).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservedName, nil
+}
+
+// AddReservedName reserves a name in an organization
+func (s *ChatService) AddReservedName(organizationID uint64, name string) (*models.ReservedName, error) {
+	name = strings.TrimSpace(name)
+	if len(utils.ConfusableSkeleton(name)) == 0 {
+		return nil, errors.New("name must contain letters or numbers")
+	}
+	reservedName := models.ReservedName{
+		OrganizationID: organizationID,
+		Name:           name,
+		CreatedDate:    time.Now(),
+	}
+	if err := s.DB.Create(&reservedName).Error; err != nil {
+		return nil, err
+	}
+	s.invalidateProtectedNames(organizationID)
+	return &reservedName, nil
+}
+
+// DeleteReservedName removes a reserved name
+func (s *ChatService) DeleteReservedName(reservedName *models.ReservedName) error {
+	err := s.DB.
+		Model(reservedName).
+		Update("deleted_date", time.Now()).
+		Error
+	if err != nil {
+		return err
+	}
+	s.invalidateProtectedNames(reservedName.OrganizationID)
+	return nil
+}
+
+// getProtectedNameCache gets the protected names of an organization, loading them from the database if they aren't
+// cached
+func (s *ChatService) getProtectedNameCache(organizationID uint64) ([]protectedName, error) {
+
+	// Lock on the cache
+	s.protectedNamesMut.Lock()
+	defer s.protectedNamesMut.Unlock()
+
+	// If the cache map is nil, create it
+	if s.protectedNames == nil {
+		s.protectedNames = map[uint64]*protectedNameCache{}
+	}
+
+	// If the cache is still fresh, use it
+	cache, ok := s.protectedNames[organizationID]
+	if ok && time.Since(cache.loadedDate) < protectedNamesCacheTTL {
+		return cache.names, nil
+	}
+
+	// Load the names, and work out their skeletons once
+	names, err := s.getProtectedNames(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range names {
+		names[i].Skeleton = utils.ConfusableSkeleton(names[i].Name)
+	}
+	s.protectedNames[organizationID] = &protectedNameCache{
+		names:      names,
+		loadedDate: time.Now(),
+	}
+	return names, nil
+
+}
+
+// invalidateProtectedNames removes the cached protected names of an organization, so they are reloaded on the next
+// check
+func (s *ChatService) invalidateProtectedNames(organizationID uint64) {
+	s.protectedNamesMut.Lock()
+	defer s.protectedNamesMut.Unlock()
+	delete(s.protectedNames, organizationID)
+}
+
+// InvalidateAllProtectedNames removes the cached protected names of every organization. It is used when a display
+// name changes, since the account can be a member of any number of organizations.
+func (s *ChatService) InvalidateAllProtectedNames() {
+	s.protectedNamesMut.Lock()
+	defer s.protectedNamesMut.Unlock()
+	s.protectedNames = nil
+}
+
+// getProtectedNames gets every name that chatters can't pose as in an organization: the reserved names, the name
+// of the organization, and the display names of the owner and the members
+func (s *ChatService) getProtectedNames(organizationID uint64) ([]protectedName, error) {
+
+	// Get the reserved names
+	reservedNames, err := s.GetReservedNames(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	names := []protectedName{}
+	for _, reservedName := range reservedNames {
+		names = append(names, protectedName{Name: reservedName.Name})
+	}
+
+	// Get the organization, whose name belongs to the owner
+	var organization models.Organization
+	err = s.DB.
+		Preload("Account").
+		Where("deleted_date IS NULL").
+		Where("id = ?", organizationID).
+		First(&organization).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return names, nil
+		}
+		return nil, err
+	}
+	names = append(names, protectedName{Name: organization.Name, AccountID: organization.AccountID})
+	if organization.Account != nil && len(organization.Account.DisplayName) > 0 {
+		names = append(names, protectedName{Name: organization.Account.DisplayName, AccountID: organization.AccountID})
+	}
+
+	// Get the display names of the members
+	var members []*models.OrganizationMember
+	err = s.DB.
+		Preload("Account").
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Find(&members).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if member.Account != nil && len(member.Account.DisplayName) > 0 {
+			names = append(names, protectedName{Name: member.Account.DisplayName, AccountID: member.AccountID})
+		}
+	}
+	return names, nil
+
+}
+
+// CheckImpersonation checks if a username looks like one of the protected names of an organization. A name that
+// belongs to the account the user is signed in with doesn't count. Exact matches are returned ahead of names
+// that are only contained in the username, and nil is returned if there is no match.
+func (s *ChatService) CheckImpersonation(
+	organizationID uint64,
+	username string,
+	account *models.Account,
+) (*ImpersonationMatch, error) {
+
+	// Usernames without any letters or numbers can't look like anything
+	skeleton := utils.ConfusableSkeleton(username)
+	if len(skeleton) == 0 {
+		return nil, nil
+	}
+
+	// Get the protected names
+	names, err := s.getProtectedNameCache(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare the username with each of the names
+	var contained *ImpersonationMatch
+	for _, name := range names {
+		if account != nil && name.AccountID == account.ID {
+			continue
+		}
+		nameSkeleton := name.Skeleton
+		if len(nameSkeleton) == 0 {
+			continue
+		}
+		if nameSkeleton == skeleton {
+			return &ImpersonationMatch{Name: name.Name, Exact: true}, nil
+		}
+		if contained == nil && len(nameSkeleton) >= minContainedSkeleton && strings.Contains(skeleton, nameSkeleton) {
+			contained = &ImpersonationMatch{Name: name.Name}
+		}
+	}
+	return contained, nil
+
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+)
+
+// ipMuteCacheTTL is how long the IP mutes of an organization are kept in memory before being reloaded
+const ipMuteCacheTTL = 30 * time.Second
+
+const (
+	// minIpv4MutePrefix is the widest IPv4 range that can be muted
+	minIpv4MutePrefix = 16
+
+	// minIpv6MutePrefix is the widest IPv6 range that can be muted
+	minIpv6MutePrefix = 32
+)
+
+// ipMuteCache holds the IP mutes of an organization in a prefix trie, so an address can be checked against
+// every muted range at once. Mutes stored as keyed hashes are kept in a map by their hash instead.
+type ipMuteCache struct {
+	trie       utils.IpTrie
+	hashed     map[string][]uint64
+	mutes      map[uint64]*models.MutedUser
+	loadedDate time.Time
+}
+
+// NormalizeMuteIpAddress validates an IP address or CIDR range to be muted, and returns it in the form it is
+// stored in. If groupIpv6 is true, IPv6 addresses are widened to their /64 network.
+func NormalizeMuteIpAddress(ipAddress string, groupIpv6 bool) (string, error) {
+
+	// Parse the address or range
+	ipNet, err := utils.ParseIpRange(ipAddress)
+	if err != nil {
+		return "", err
+	}
+
+	// Group IPv6 addresses if requested
+	if groupIpv6 {
+		ipNet = utils.GroupIpv6(ipNet)
+	}
+
+	// Don't allow ranges so wide they would mute huge numbers of unrelated users
+	ones, bits := ipNet.Mask.Size()
+	if bits == 32 && ones < minIpv4MutePrefix {
+		return "", fmt.Errorf("IPv4 ranges cannot be wider than /%d", minIpv4MutePrefix)
+	}
+	if bits == 128 && ones < minIpv6MutePrefix {
+		return "", fmt.Errorf("IPv6 ranges cannot be wider than /%d", minIpv6MutePrefix)
+	}
+
+	return utils.IpRangeString(ipNet), nil
+
+}
+
+// getIpMuteCache gets the IP mutes of an organization, loading them from the database if they aren't cached
+func (s *ChatService) getIpMuteCache(organizationID uint64) (*ipMuteCache, error) {
+
+	// Lock on the cache
+	s.ipMutesMut.Lock()
+	defer s.ipMutesMut.Unlock()
+
+	// If the cache map is nil, create it
+	if s.ipMutes == nil {
+		s.ipMutes = map[uint64]*ipMuteCache{}
+	}
+
+	// If the cache is still fresh, use it
+	cache, ok := s.ipMutes[organizationID]
+	if ok && time.Since(cache.loadedDate) < ipMuteCacheTTL {
+		return cache, nil
+	}
+
+	// Load all of the active IP mutes in the organization
+	var mutes []*models.MutedUser
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("until_date IS NULL OR until_date > ?", time.Now()).
+		Where("organization_id = ?", organizationID).
+		Where("ip_address IS NOT NULL").
+		Find(&mutes).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the trie of the muted ranges
+	cache = &ipMuteCache{
+		hashed:     map[string][]uint64{},
+		mutes:      map[uint64]*models.MutedUser{},
+		loadedDate: time.Now(),
+	}
+	for _, mute := range mutes {
+		if utils.IsHashedIp(mute.IpAddress.String) {
+			cache.hashed[mute.IpAddress.String] = append(cache.hashed[mute.IpAddress.String], mute.ID)
+			cache.mutes[mute.ID] = mute
+			continue
+		}
+		ipNet, err := utils.ParseIpRange(mute.IpAddress.String)
+		if err != nil {
+			continue
+		}
+		cache.trie.Insert(ipNet, mute.ID)
+		cache.mutes[mute.ID] = mute
+	}
+	s.ipMutes[organizationID] = cache
+	return cache, nil
+
+}
+
+// invalidateIpMutes removes the cached IP mutes of an organization, so they are reloaded on the next check
+func (s *ChatService) invalidateIpMutes(organizationID uint64) {
+	s.ipMutesMut.Lock()
+	defer s.ipMutesMut.Unlock()
+	delete(s.ipMutes, organizationID)
+}
+
+// match gets the IDs of the mutes on every range containing an IP address, and on the hashes of the address
+func (cache *ipMuteCache) match(hasher *utils.IpHasher, ip net.IP) []uint64 {
+	muteIDs := cache.trie.Match(ip)
+	for _, hashed := range hasher.HashedForms(ip.String()) {
+		muteIDs = append(muteIDs, cache.hashed[hashed]...)
+	}
+	return muteIDs
+}
+
+// getIpMuteIDs gets the IDs of the mutes in an organization that apply to an IP address. Nothing is returned if
+// the value isn't a single address.
+func (s *ChatService) getIpMuteIDs(organizationID uint64, ipAddress string) ([]uint64, error) {
+
+	// Parse the address
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if ip == nil {
+		return nil, nil
+	}
+
+	// Get the IP mutes of the organization
+	cache, err := s.getIpMuteCache(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return cache.match(s.IpHasher, ip), nil
+
+}
+
+// getIpMutes gets the active mutes in a chat room that apply to an IP address
+func (s *ChatService) getIpMutes(chatRoom *models.ChatRoom, ipAddress string) ([]*models.MutedUser, error) {
+
+	// Parse the address
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if ip == nil {
+		return nil, nil
+	}
+
+	// Get the IP mutes of the organization
+	cache, err := s.getIpMuteCache(chatRoom.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the mutes that apply in the chat room
+	mutes := []*models.MutedUser{}
+	for _, muteID := range cache.match(s.IpHasher, ip) {
+		mute := cache.mutes[muteID]
+		if !mute.IsActive() {
+			continue
+		}
+		if mute.ChatRoomID.Valid && uint64(mute.ChatRoomID.Int64) != chatRoom.ID {
+			continue
+		}
+		mutes = append(mutes, mute)
+	}
+	return mutes, nil
+
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+)
+
+// GetLinkDomains gets the domains on the allow list and the block list of an organization
+func (s *ChatService) GetLinkDomains(organizationID uint64) ([]*models.LinkDomain, error) {
+	var linkDomains []*models.LinkDomain
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Order("domain ASC").
+		Find(&linkDomains).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return linkDomains, nil
+}
+
+// UpdateLinkPolicy changes the link policy of an organization
+func (s *ChatService) UpdateLinkPolicy(organization *models.Organization, policy string) error {
+	if !models.IsValidLinkPolicy(policy) {
+		return errors.New("invalid link policy")
+	}
+	organization.LinkPolicy = policy
+	return s.DB.
+		Model(organization).
+		Select("link_policy").
+		Updates(organization).
+		Error
+}
+
+// SetLinkDomains replaces the allow list or the block list of an organization with a new list of domains
+func (s *ChatService) SetLinkDomains(organizationID uint64, allowed bool, domains []string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+
+		// Remove the domains currently on the list
+		err := tx.
+			Model(&models.LinkDomain{}).
+			Where("deleted_date IS NULL").
+			Where("organization_id = ?", organizationID).
+			Where("allowed = ?", allowed).
+			Update("deleted_date", time.Now()).
+			Error
+		if err != nil {
+			return err
+		}
+
+		// Add each of the new domains, in the same form they are compared in
+		seen := map[string]bool{}
+		for _, domain := range domains {
+			domain = utils.NormalizeDomain(domain)
+			if len(domain) == 0 || seen[domain] {
+				continue
+			}
+			seen[domain] = true
+			linkDomain := models.LinkDomain{
+				OrganizationID: organizationID,
+				Domain:         domain,
+				Allowed:        allowed,
+				CreatedDate:    time.Now(),
+			}
+			if err := tx.Create(&linkDomain).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+
+	})
+}
+
+// checkLinkPolicy checks the links in a message against the link policy of the chat room's organization. It
+// returns LinkPolicyBlock or LinkPolicyHold if the message should be blocked or held, along with the domain that
+// caused it, or an empty action if the message can be sent.
+func (s *ChatService) checkLinkPolicy(chatRoom *models.ChatRoom, message string) (string, string, error) {
+
+	// If there are no links, there's nothing to check
+	domains := utils.FindLinkDomains(message)
+	if len(domains) == 0 {
+		return "", "", nil
+	}
+
+	// Get the link policy of the organization
+	var organization models.Organization
+	err := s.DB.
+		Select("id", "link_policy").
+		Where("id = ?", chatRoom.OrganizationID).
+		First(&organization).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	if organization.LinkPolicy == models.LinkPolicyOff {
+		return "", "", nil
+	}
+	if organization.LinkPolicy == models.LinkPolicyBlock {
+		return models.LinkPolicyBlock, domains[0], nil
+	}
+
+	// Get the domain lists of the organization
+	linkDomains, err := s.GetLinkDomains(chatRoom.OrganizationID)
+	if err != nil {
+		return "", "", err
+	}
+	isListed := func(domain string, allowed bool) bool {
+		for _, linkDomain := range linkDomains {
+			if linkDomain.Allowed == allowed && utils.DomainMatches(domain, linkDomain.Domain) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Check each of the domains against the lists
+	for _, domain := range domains {
+		switch organization.LinkPolicy {
+		case models.LinkPolicyAllowList:
+			if !isListed(domain, true) {
+				return models.LinkPolicyBlock, domain, nil
+			}
+		case models.LinkPolicyBlockList:
+			if isListed(domain, false) {
+				return models.LinkPolicyBlock, domain, nil
+			}
+		case models.LinkPolicyHold:
+			if !isListed(domain, true) {
+				return models.LinkPolicyHold, domain, nil
+			}
+		}
+	}
+	return "", "", nil
+
+}
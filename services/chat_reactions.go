@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+)
+
+// maxReactions is the most emoji an organization can allow reactions with
+const maxReactions = 20
+
+// DefaultReactions are the emoji viewers can react with when an organization hasn't chosen its own
+var DefaultReactions = []string{"👍", "❤️", "😂", "😮", "😢", "🔥", "👏", "🎉"}
+
+// GetReactions gets the emoji viewers can react to messages with in an organization's chat rooms
+func GetReactions(organization *models.Organization) []string {
+	reactions := strings.Fields(organization.Reactions)
+	if len(reactions) == 0 {
+		return DefaultReactions
+	}
+	return reactions
+}
+
+// IsAllowedReaction checks if viewers can react with an emoji in an organization's chat rooms
+func IsAllowedReaction(organization *models.Organization, emoji string) bool {
+	for _, reaction := range GetReactions(organization) {
+		if reaction == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateReactions changes the emoji viewers can react with in an organization's chat rooms. An empty list goes back
+// to the default set.
+func (s *ChatService) UpdateReactions(organization *models.Organization, reactions []string) error {
+
+	// Check each of the emoji, without any duplicates
+	seen := map[string]bool{}
+	emoji := []string{}
+	for _, reaction := range reactions {
+		reaction = strings.TrimSpace(reaction)
+		if !utils.IsEmoji(reaction) {
+			return errors.New("reactions must be emoji")
+		}
+		if seen[reaction] {
+			continue
+		}
+		seen[reaction] = true
+		emoji = append(emoji, reaction)
+	}
+	if len(emoji) > maxReactions {
+		return errors.New("too many reactions")
+	}
+
+	// Save the set of reactions
+	organization.Reactions = strings.Join(emoji, " ")
+	return s.DB.
+		Model(organization).
+		Select("reactions").
+		Updates(organization).
+		Error
+
+}
+
+// reactionIdentity gets the identity a reaction is counted under, so each chatter can only react once with each
+// emoji. Signed in accounts are counted by account, and everyone else by IP address, since usernames are picked by
+// the viewer and could be changed to react again, or to take back someone else's reaction.
+func reactionIdentity(user *ChatUserInfo) string {
+	if user.Account != nil {
+		return fmt.Sprintf("account:%d", user.Account.ID)
+	}
+	return "ip:" + user.IpAddress
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+)
+
+// GetSpamRules gets all of the spam rules of an organization
+func (s *ChatService) GetSpamRules(organizationID uint64) ([]*models.SpamRule, error) {
+	var spamRules []*models.SpamRule
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Order("id ASC").
+		Find(&spamRules).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return spamRules, nil
+}
+
+// GetSpamRuleByID gets the spam rule with the provided ID
+func (s *ChatService) GetSpamRuleByID(id uint64) (*models.SpamRule, error) {
+	var spamRule models.SpamRule
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&spamRule).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &spamRule, nil
+}
+
+// SerializeSpamRule converts a spam rule into a map
+func SerializeSpamRule(rule *models.SpamRule) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              rule.ID,
+		"heuristic":       rule.Heuristic,
+		"threshold":       rule.Threshold,
+		"min_length":      rule.MinLength,
+		"window_seconds":  rule.WindowSeconds,
+		"action":          rule.Action,
+		"timeout_seconds": utils.FlattenNullInt64(rule.TimeoutSeconds),
+		"created_date":    rule.CreatedDate.UTC().Unix(),
+	}
+}
+
+// ValidateSpamRule makes sure the settings of a spam rule make sense for its heuristic
+func ValidateSpamRule(rule *models.SpamRule) error {
+
+	// Check the heuristic and the action
+	if !models.IsValidSpamHeuristic(rule.Heuristic) {
+		return errors.New("invalid heuristic")
+	}
+	if !models.IsValidSpamAction(rule.Action) {
+		return errors.New("invalid action")
+	}
+	if rule.Action == models.SpamActionTimeout && (!rule.TimeoutSeconds.Valid || rule.TimeoutSeconds.Int64 <= 0) {
+		return errors.New("timeout duration must be positive")
+	}
+	if rule.MinLength < 0 {
+		return errors.New("minimum length cannot be negative")
+	}
+
+	// Check the threshold for the heuristic
+	switch rule.Heuristic {
+	case models.SpamHeuristicDuplicate:
+		if rule.Threshold < 1 {
+			return errors.New("duplicate threshold must be at least 1")
+		}
+		if rule.WindowSeconds <= 0 {
+			return errors.New("duplicate window must be positive")
+		}
+	case models.SpamHeuristicCaps, models.SpamHeuristicSymbols:
+		if rule.Threshold <= 0 || rule.Threshold > 1 {
+			return errors.New("ratio threshold must be between 0 and 1")
+		}
+	case models.SpamHeuristicLength:
+		if rule.Threshold < 1 {
+			return errors.New("length threshold must be at least 1")
+		}
+	case models.SpamHeuristicRepeatedChars:
+		if rule.Threshold < 2 {
+			return errors.New("repeated character threshold must be at least 2")
+		}
+	}
+	return nil
+
+}
+
+// SaveSpamRule creates or updates a spam rule, after making sure its settings are valid
+func (s *ChatService) SaveSpamRule(rule *models.SpamRule) error {
+	if err := ValidateSpamRule(rule); err != nil {
+		return err
+	}
+	if rule.ID == 0 {
+		rule.CreatedDate = time.Now()
+		return s.DB.Create(rule).Error
+	}
+	return s.DB.
+		Model(rule).
+		Select("heuristic", "threshold", "min_length", "window_seconds", "action", "timeout_seconds").
+		Updates(rule).
+		Error
+}
+
+// DeleteSpamRule removes a spam rule
+func (s *ChatService) DeleteSpamRule(rule *models.SpamRule) error {
+	return s.DB.
+		Model(rule).
+		Update("deleted_date", time.Now()).
+		Error
+}
+
+// messageBreaksSpamRule checks if a message sent by a user breaks a spam rule
+func (s *ChatService) messageBreaksSpamRule(
+	chatRoom *models.ChatRoom,
+	user *ChatUserInfo,
+	message string,
+	rule *models.SpamRule,
+) (bool, error) {
+	switch rule.Heuristic {
+	case models.SpamHeuristicDuplicate:
+		count, err := s.countRecentDuplicates(chatRoom.OrganizationID, user, message, rule.WindowSeconds)
+		if err != nil {
+			return false, err
+		}
+		return float64(count) >= rule.Threshold, nil
+	case models.SpamHeuristicCaps:
+		ratio, letters := utils.CapsRatio(message)
+		return letters >= rule.MinLength && ratio > rule.Threshold, nil
+	case models.SpamHeuristicSymbols:
+		ratio, chars := utils.SymbolRatio(message)
+		return chars >= rule.MinLength && ratio > rule.Threshold, nil
+	case models.SpamHeuristicLength:
+		return float64(utils.MessageLength(message)) > rule.Threshold, nil
+	case models.SpamHeuristicRepeatedChars:
+		return float64(utils.LongestRun(message)) > rule.Threshold, nil
+	}
+	return false, nil
+}
+
+// countRecentDuplicates counts the messages in the chat history of an organization that a user sent with the same
+// text within the last few seconds. Removed messages still count, so spam that was cleaned up isn't forgiven. Messages
+// are matched by username, IP address or account, so switching usernames doesn't reset the count.
+func (s *ChatService) countRecentDuplicates(
+	organizationID uint64,
+	user *ChatUserInfo,
+	message string,
+	windowSeconds int64,
+) (int64, error) {
+
+	// Match any message from the same sender
+	ors := s.DB
+	matched := false
+	if len(user.Username) > 0 {
+		ors = ors.Or(usernameQuery("username"), user.Username)
+		matched = true
+	}
+	if len(user.IpAddress) > 0 {
+		ors = ors.Or("ip_address IN ?", s.IpHasher.StoredForms(user.IpAddress))
+		matched = true
+	}
+	if user.Account != nil {
+		ors = ors.Or("account_id = ?", user.Account.ID)
+		matched = true
+	}
+	if !matched {
+		return 0, nil
+	}
+
+	// Count the messages with the same text
+	var count int64
+	err := s.DB.
+		Model(&models.ChatMessage{}).
+		Where("organization_id = ?", organizationID).
+		Where(ors).
+		Where("LOWER(TRIM(message)) = ?", strings.ToLower(strings.TrimSpace(message))).
+		Where("created_date > ?", time.Now().Add(-time.Second*time.Duration(windowSeconds))).
+		Count(&count).
+		Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// checkSpamRules gets the first spam rule of an organization that a message breaks, or nil if it breaks none
+func (s *ChatService) checkSpamRules(
+	chatRoom *models.ChatRoom,
+	user *ChatUserInfo,
+	message string,
+) (*models.SpamRule, error) {
+
+	// Get the rules of the organization
+	spamRules, err := s.GetSpamRules(chatRoom.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the first rule the message breaks
+	for _, rule := range spamRules {
+		broken, err := s.messageBreaksSpamRule(chatRoom, user, message, rule)
+		if err != nil {
+			return nil, err
+		}
+		if broken {
+			return rule, nil
+		}
+	}
+	return nil, nil
+
+}
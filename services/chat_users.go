@@ -0,0 +1,223 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// chatUserKey gets the form of a username stored on chat user profiles
+func chatUserKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// usernameQuery matches a column against a username exactly, ignoring case. LIKE isn't used, so the wildcards in a
+// username can't match anyone else.
+func usernameQuery(column string) string {
+	return "LOWER(" + column + ") = LOWER(?)"
+}
+
+// SerializeChatUser converts the profile of a chatter into a map
+func SerializeChatUser(chatUser *models.ChatUser) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              chatUser.ID,
+		"organization_id": chatUser.OrganizationID,
+		"username":        chatUser.Username,
+		"first_seen_date": chatUser.FirstSeenDate.UTC().Unix(),
+		"last_seen_date":  chatUser.LastSeenDate.UTC().Unix(),
+		"message_count":   chatUser.MessageCount,
+		"mutes_received":  chatUser.MutesReceived,
+		"followed_date":   utils.FlattenNullTimeSec(chatUser.FollowedDate),
+		"trust_level":     chatUser.TrustLevel(time.Now()),
+	}
+}
+
+// GetChatUser gets the profile of a chatter in an organization, or nil if they have never chatted there
+func (s *ChatService) GetChatUser(organizationID uint64, username string) (*models.ChatUser, error) {
+	var chatUser models.ChatUser
+	err := s.DB.
+		Where("organization_id = ?", organizationID).
+		Where("username = ?", chatUserKey(username)).
+		First(&chatUser).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &chatUser, nil
+}
+
+// TouchChatUser gets the profile of a chatter in an organization, creating it the first time they are seen, and
+// marks them as seen now. New profiles belong to the chatter's account or IP address. Profiles from before owners
+// were recorded are claimed by the next chatter, starting over as if they were new. Chatters without a username
+// don't have a profile.
+func (s *ChatService) TouchChatUser(organizationID uint64, user *ChatUserInfo) (*models.ChatUser, error) {
+
+	// Chatters need a username to have a profile
+	key := chatUserKey(user.Username)
+	if len(key) == 0 {
+		return nil, nil
+	}
+
+	// Work out who the profile would belong to
+	var ownerAccountID sql.NullInt64
+	var ownerIpAddress sql.NullString
+	if user.Account != nil {
+		ownerAccountID = sql.NullInt64{Valid: true, Int64: int64(user.Account.ID)}
+	} else {
+		ownerIpAddress = sql.NullString{Valid: true, String: s.IpHasher.HashIp(user.IpAddress)}
+	}
+
+	// Create the profile if it doesn't exist yet. Two messages can arrive at once, so a profile created in the
+	// meantime is left alone.
+	now := time.Now()
+	err := s.DB.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ChatUser{
+			OrganizationID: organizationID,
+			Username:       key,
+			FirstSeenDate:  now,
+			LastSeenDate:   now,
+			OwnerAccountID: ownerAccountID,
+			OwnerIpAddress: ownerIpAddress,
+		}).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim a profile without an owner, starting its history over
+	err = s.DB.
+		Model(&models.ChatUser{}).
+		Where("organization_id = ?", organizationID).
+		Where("username = ?", key).
+		Where("owner_account_id IS NULL AND owner_ip_address IS NULL").
+		Updates(map[string]interface{}{
+			"owner_account_id":    ownerAccountID,
+			"owner_ip_address":    ownerIpAddress,
+			"first_seen_date":     now,
+			"message_count":       0,
+			"followed_date":       nil,
+			"follower_account_id": nil,
+			"follower_ip_address": nil,
+		}).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark the chatter as seen
+	err = s.DB.
+		Model(&models.ChatUser{}).
+		Where("organization_id = ?", organizationID).
+		Where("username = ?", key).
+		Update("last_seen_date", now).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetChatUser(organizationID, key)
+
+}
+
+// incrementChatUser adds one to a counter on the profile of a chatter, if they have one
+func (s *ChatService) incrementChatUser(organizationID uint64, username string, column string) error {
+	if len(chatUserKey(username)) == 0 {
+		return nil
+	}
+	return s.DB.
+		Model(&models.ChatUser{}).
+		Where("organization_id = ?", organizationID).
+		Where("username = ?", chatUserKey(username)).
+		UpdateColumn(column, gorm.Expr(column+" + 1")).
+		Error
+}
+
+// isChatter checks if a chatter is the one identified by an account or stored IP address. Chatters identified by
+// an account have to be signed in with it.
+func (s *ChatService) isChatter(accountID sql.NullInt64, ipAddress sql.NullString, user *ChatUserInfo) bool {
+	if accountID.Valid {
+		return user.Account != nil && uint64(accountID.Int64) == user.Account.ID
+	}
+	if !ipAddress.Valid || len(user.IpAddress) == 0 {
+		return false
+	}
+	for _, form := range s.IpHasher.StoredForms(user.IpAddress) {
+		if form == ipAddress.String {
+			return true
+		}
+	}
+	return false
+}
+
+// isChatUserOwner checks if a chatter is the one a profile belongs to, so its history counts for them
+func (s *ChatService) isChatUserOwner(chatUser *models.ChatUser, user *ChatUserInfo) bool {
+	return s.isChatter(chatUser.OwnerAccountID, chatUser.OwnerIpAddress, user)
+}
+
+// isFollower checks if a chatter is the one who followed under the username of a profile
+func (s *ChatService) isFollower(chatUser *models.ChatUser, user *ChatUserInfo) bool {
+	return s.isChatter(chatUser.FollowerAccountID, chatUser.FollowerIpAddress, user)
+}
+
+// SetChatUserFollowing marks a chatter as following the organization, or not. Only the owner of the profile can
+// follow under its username, and the follow is tied to their account or IP address.
+func (s *ChatService) SetChatUserFollowing(
+	organizationID uint64,
+	user *ChatUserInfo,
+	following bool,
+) (*models.ChatUser, error) {
+
+	// Get the profile of the chatter, which has to be theirs
+	chatUser, err := s.TouchChatUser(organizationID, user)
+	if err != nil {
+		return nil, err
+	}
+	if chatUser == nil {
+		return nil, errors.New("a username is required to follow")
+	}
+	if !s.isChatUserOwner(chatUser, user) {
+		return nil, errors.New("username belongs to another chatter")
+	}
+
+	// If nothing would change, leave the follow date alone
+	if chatUser.FollowedDate.Valid == following {
+		return chatUser, nil
+	}
+
+	// Only the chatter who followed can unfollow
+	if !following && !s.isFollower(chatUser, user) {
+		return nil, errors.New("not allowed to unfollow this user")
+	}
+
+	// Update the follow date, and who followed
+	chatUser.FollowedDate = sql.NullTime{}
+	chatUser.FollowerAccountID = sql.NullInt64{}
+	chatUser.FollowerIpAddress = sql.NullString{}
+	if following {
+		chatUser.FollowedDate = sql.NullTime{Valid: true, Time: time.Now()}
+		if user.Account != nil {
+			chatUser.FollowerAccountID = sql.NullInt64{Valid: true, Int64: int64(user.Account.ID)}
+		} else {
+			chatUser.FollowerIpAddress = sql.NullString{Valid: true, String: s.IpHasher.HashIp(user.IpAddress)}
+		}
+	}
+	err = s.DB.
+		Model(chatUser).
+		Select("followed_date", "follower_account_id", "follower_ip_address").
+		Updates(chatUser).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return chatUser, nil
+
+}
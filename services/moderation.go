@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+)
+
+// ModerationService manages the log of moderation actions
+type ModerationService struct {
+	DB       *gorm.DB
+	IpHasher *utils.IpHasher
+}
+
+// ModerationActor identifies who performed a moderation action
+type ModerationActor struct {
+	Type      string
+	AccountID uint64
+	Rule      string
+	Username  string
+}
+
+// AccountActor creates the actor for an action taken by a moderator's account
+func AccountActor(account *models.Account) *ModerationActor {
+	return &ModerationActor{
+		Type:      models.ModerationActorAccount,
+		AccountID: account.ID,
+	}
+}
+
+// AutomodActor creates the actor for an action taken automatically by an automod rule
+func AutomodActor(rule string) *ModerationActor {
+	return &ModerationActor{
+		Type: models.ModerationActorAutomod,
+		Rule: rule,
+	}
+}
+
+// ViewerActor creates the actor for an action taken by a viewer in the chat
+func ViewerActor(username string) *ModerationActor {
+	return &ModerationActor{
+		Type:     models.ModerationActorViewer,
+		Username: username,
+	}
+}
+
+// ModerationEventInfo describes a moderation action to be recorded
+type ModerationEventInfo struct {
+	OrganizationID uint64
+	ChatRoom       *models.ChatRoom
+	Actor          *ModerationActor
+	Action         string
+	Target         *ChatUserInfo
+	Reason         string
+	MessageID      string
+}
+
+func nullString(str string) sql.NullString {
+	return sql.NullString{
+		Valid:  len(str) > 0,
+		String: str,
+	}
+}
+
+// RecordEvent appends a moderation action to the log
+func (s *ModerationService) RecordEvent(info *ModerationEventInfo) (*models.ModerationEvent, error) {
+
+	// Create the event
+	event := models.ModerationEvent{
+		OrganizationID: info.OrganizationID,
+		ActorType:      info.Actor.Type,
+		ActorRule:      nullString(info.Actor.Rule),
+		ActorUsername:  nullString(info.Actor.Username),
+		Action:         info.Action,
+		Reason:         info.Reason,
+		MessageID:      nullString(info.MessageID),
+		CreatedDate:    time.Now(),
+	}
+	if info.ChatRoom != nil {
+		event.ChatRoomID = sql.NullInt64{
+			Valid: true,
+			Int64: int64(info.ChatRoom.ID),
+		}
+	}
+	if info.Actor.AccountID > 0 {
+		event.ActorAccountID = sql.NullInt64{
+			Valid: true,
+			Int64: int64(info.Actor.AccountID),
+		}
+	}
+	if info.Target != nil {
+		event.TargetUsername = nullString(info.Target.Username)
+		event.TargetIpAddress = nullString(s.IpHasher.HashIp(info.Target.IpAddress))
+	}
+
+	// Save it to the log
+	if err := s.DB.Create(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+
+}
+
+// ModerationEventFilter narrows down the moderation events returned from the log
+type ModerationEventFilter struct {
+	OrganizationID  uint64
+	ChatRoomID      uint64
+	ActorType       string
+	ActorAccountID  uint64
+	Action          string
+	TargetUsername  string
+	TargetIpAddress string
+	SinceDate       *time.Time
+	UntilDate       *time.Time
+	Offset          int
+	Limit           int
+}
+
+// ListEvents gets the moderation events matching a filter, newest first, along with the total number of events
+// that match the filter regardless of paging. A limit of zero returns every matching event.
+func (s *ModerationService) ListEvents(filter *ModerationEventFilter) ([]*models.ModerationEvent, int64, error) {
+
+	// Construct the query
+	query := s.DB.
+		Model(&models.ModerationEvent{}).
+		Where("organization_id = ?", filter.OrganizationID)
+
+	// Add each of the filters provided
+	if filter.ChatRoomID > 0 {
+		query = query.Where("chat_room_id = ?", filter.ChatRoomID)
+	}
+	if len(filter.ActorType) > 0 {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.ActorAccountID > 0 {
+		query = query.Where("actor_account_id = ?", filter.ActorAccountID)
+	}
+	if len(filter.Action) > 0 {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if len(filter.TargetUsername) > 0 {
+		query = query.Where("target_username LIKE ?", filter.TargetUsername)
+	}
+	if len(filter.TargetIpAddress) > 0 {
+		query = query.Where("target_ip_address IN ?", s.IpHasher.StoredForms(filter.TargetIpAddress))
+	}
+	if filter.SinceDate != nil {
+		query = query.Where("created_date >= ?", *filter.SinceDate)
+	}
+	if filter.UntilDate != nil {
+		query = query.Where("created_date < ?", *filter.UntilDate)
+	}
+
+	// Count all of the matching events
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get the page of events
+	query = query.Order("created_date DESC").Order("id DESC")
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var events []*models.ModerationEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+
+}
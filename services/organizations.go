@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"gorm.io/gorm"
+)
+
+// OrganizationsService manages organizations and the accounts that have access to them
+type OrganizationsService struct {
+	DB *gorm.DB
+}
+
+// GetOrganizationByID gets the organization with the provided ID
+func (s *OrganizationsService) GetOrganizationByID(id uint64) (*models.Organization, error) {
+	var organization models.Organization
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("id = ?", id).
+		First(&organization).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// GetAccountRole gets the role an account has within an organization. If the account has no access to the
+// organization, an empty string is returned.
+func (s *OrganizationsService) GetAccountRole(account *models.Account, organizationID uint64) (string, error) {
+
+	// If there is no account, it has no role
+	if account == nil {
+		return "", nil
+	}
+
+	// Get the organization
+	organization, err := s.GetOrganizationByID(organizationID)
+	if err != nil {
+		return "", err
+	}
+	if organization == nil {
+		return "", nil
+	}
+
+	// The account that owns the organization is always the owner
+	if organization.AccountID == account.ID {
+		return models.RoleOwner, nil
+	}
+
+	// Find the membership of the account in the organization
+	var member models.OrganizationMember
+	err = s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Where("account_id = ?", account.ID).
+		First(&member).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+
+}
+
+// AccountHasRole checks if an account has at least the minimum role within an organization
+func (s *OrganizationsService) AccountHasRole(
+	account *models.Account,
+	organizationID uint64,
+	minimum string,
+) (bool, error) {
+	role, err := s.GetAccountRole(account, organizationID)
+	if err != nil {
+		return false, err
+	}
+	return models.RoleAtLeast(role, minimum), nil
+}
+
+// CanAccountModerate checks if an account is allowed to moderate the chat rooms in an organization
+func (s *OrganizationsService) CanAccountModerate(account *models.Account, organizationID uint64) (bool, error) {
+	return s.AccountHasRole(account, organizationID, models.RoleModerator)
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	"gorm.io/gorm"
+)
+
+// PrivacyService handles data subject requests from chat viewers, exporting or erasing the data held about them
+type PrivacyService struct {
+	DB          *gorm.DB
+	IpHasher    *utils.IpHasher
+	ChatService *ChatService
+}
+
+// UserData is everything held about a chat user within an organization
+type UserData struct {
+	ChatUser         *models.ChatUser
+	Messages         []*models.ChatMessage
+	Mutes            []*models.MutedUser
+	ModerationEvents []*models.ModerationEvent
+	BadgeAssignments []*models.BadgeAssignment
+	PinnedChatRooms  []*models.ChatRoom
+}
+
+// userQuery narrows a query down to the rows matching a user's username or IP address, in the given columns
+func (s *PrivacyService) userQuery(user *ChatUserInfo, usernameColumns []string, ipColumn string) *gorm.DB {
+	ors := s.DB
+	if len(user.Username) > 0 {
+		for _, column := range usernameColumns {
+			ors = ors.Or(usernameQuery(column), user.Username)
+		}
+	}
+	if len(user.IpAddress) > 0 {
+		ors = ors.Or(ipColumn+" IN ?", s.IpHasher.StoredForms(user.IpAddress))
+	}
+	return ors
+}
+
+// ExportUserData gets everything held about a user within an organization, matched by username or IP address
+func (s *PrivacyService) ExportUserData(organizationID uint64, user *ChatUserInfo) (*UserData, error) {
+
+	// If the user info is missing both fields
+	var data UserData
+	if len(user.Username) == 0 && len(user.IpAddress) == 0 {
+		return &data, nil
+	}
+
+	// Get the profile of the user
+	chatUser, err := s.ChatService.GetChatUser(organizationID, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	data.ChatUser = chatUser
+
+	// Get the messages sent by the user
+	err = s.DB.
+		Where("organization_id = ?", organizationID).
+		Where(s.userQuery(user, []string{"username"}, "ip_address")).
+		Order("created_date ASC").
+		Find(&data.Messages).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the mutes on the user
+	err = s.DB.
+		Where("organization_id = ?", organizationID).
+		Where(s.userQuery(user, []string{"username"}, "ip_address")).
+		Order("created_date ASC").
+		Find(&data.Mutes).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the moderation events involving the user
+	err = s.DB.
+		Where("organization_id = ?", organizationID).
+		Where(s.userQuery(user, []string{"target_username", "actor_username"}, "target_ip_address")).
+		Order("created_date ASC").
+		Find(&data.ModerationEvents).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the badges assigned to the user's username
+	if len(user.Username) > 0 {
+		err = s.DB.
+			Preload("ChatRoom").
+			Where("organization_id = ?", organizationID).
+			Where(usernameQuery("username"), user.Username).
+			Order("created_date ASC").
+			Find(&data.BadgeAssignments).
+			Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Get the chat rooms with one of the user's messages pinned
+	data.PinnedChatRooms, err = s.GetPinnedChatRooms(organizationID, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+
+}
+
+// GetPinnedChatRooms gets the chat rooms in an organization with one of a user's messages pinned
+func (s *PrivacyService) GetPinnedChatRooms(organizationID uint64, user *ChatUserInfo) ([]*models.ChatRoom, error) {
+	chatRooms := []*models.ChatRoom{}
+	if len(user.Username) == 0 {
+		return chatRooms, nil
+	}
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("organization_id = ?", organizationID).
+		Where(usernameQuery("pinned_username"), user.Username).
+		Find(&chatRooms).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return chatRooms, nil
+}
+
+// EraseUserData erases everything held about a user within an organization, matched by username or IP address.
+// The profile, messages, mutes, badge assignments and pins are deleted, and moderation events are kept with the user's details removed so
+// the log remains intact.
+func (s *PrivacyService) EraseUserData(organizationID uint64, user *ChatUserInfo) error {
+
+	// If the user info is missing both fields
+	if len(user.Username) == 0 && len(user.IpAddress) == 0 {
+		return nil
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+
+		// Delete the messages sent by the user
+		err := tx.
+			Where("organization_id = ?", organizationID).
+			Where(s.userQuery(user, []string{"username"}, "ip_address")).
+			Delete(&models.ChatMessage{}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		// Delete the profile of the user
+		if len(user.Username) > 0 {
+			err = tx.
+				Where("organization_id = ?", organizationID).
+				Where("username = ?", chatUserKey(user.Username)).
+				Delete(&models.ChatUser{}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Delete the mutes on the user
+		err = tx.
+			Where("organization_id = ?", organizationID).
+			Where(s.userQuery(user, []string{"username"}, "ip_address")).
+			Delete(&models.MutedUser{}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		// Remove the user's details from the moderation events they were the target of
+		err = tx.
+			Model(&models.ModerationEvent{}).
+			Where("organization_id = ?", organizationID).
+			Where(s.userQuery(user, []string{"target_username"}, "target_ip_address")).
+			Updates(map[string]interface{}{
+				"target_username":   nil,
+				"target_ip_address": nil,
+			}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		// Delete the badges assigned to the user's username, and unpin their messages
+		if len(user.Username) > 0 {
+			err = tx.
+				Where("organization_id = ?", organizationID).
+				Where(usernameQuery("username"), user.Username).
+				Delete(&models.BadgeAssignment{}).
+				Error
+			if err != nil {
+				return err
+			}
+			err = tx.
+				Model(&models.ChatRoom{}).
+				Where("organization_id = ?", organizationID).
+				Where(usernameQuery("pinned_username"), user.Username).
+				Updates(map[string]interface{}{
+					"pinned_message_id": nil,
+					"pinned_username":   nil,
+					"pinned_photo_url":  nil,
+					"pinned_message":    nil,
+					"pinned_until_date": nil,
+				}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Remove the user's details from the moderation events they performed
+		if len(user.Username) > 0 {
+			err = tx.
+				Model(&models.ModerationEvent{}).
+				Where("organization_id = ?", organizationID).
+				Where(usernameQuery("actor_username"), user.Username).
+				Update("actor_username", nil).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		return err
+	}
+
+	// The user's IP mutes are gone, so the cached mutes are stale
+	s.ChatService.invalidateIpMutes(organizationID)
+	return nil
+
+}
+
+// SerializeChatMessage converts a message from the chat history to a map
+func SerializeChatMessage(message *models.ChatMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           message.MessageID,
+		"chat_room_id": message.ChatRoomID,
+		"username":     message.Username,
+		"photo_url":    message.PhotoUrl,
+		"ip_address":   message.IpAddress,
+		"message":      message.Message,
+		"reply_to":     utils.FlattenNullString(message.ReplyToMessageID),
+		"created_date": message.CreatedDate.UTC().Unix(),
+		"edited_date":  utils.FlattenNullTimeSec(message.EditedDate),
+		"cleared_date": utils.FlattenNullTimeSec(message.ClearedDate),
+		"deleted_date": utils.FlattenNullTimeSec(message.DeletedDate),
+	}
+}
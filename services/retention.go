@@ -0,0 +1,131 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"gorm.io/gorm"
+)
+
+// RetentionService enforces the data retention policies of organizations
+type RetentionService struct {
+	DB *gorm.DB
+}
+
+// retentionCutoff gets the date before which data should be purged for a retention window in days. If the
+// window isn't set, false is returned.
+func retentionCutoff(days sql.NullInt64) (time.Time, bool) {
+	if !days.Valid || days.Int64 < 0 {
+		return time.Time{}, false
+	}
+	return time.Now().Add(-time.Hour * 24 * time.Duration(days.Int64)), true
+}
+
+// UpdateRetention changes the retention windows of an organization. Nil values keep the data forever.
+func (s *RetentionService) UpdateRetention(
+	organization *models.Organization,
+	messageDays *int64,
+	muteDays *int64,
+	logDays *int64,
+) error {
+
+	// Convert each of the windows
+	toNull := func(days *int64) sql.NullInt64 {
+		if days == nil {
+			return sql.NullInt64{}
+		}
+		return sql.NullInt64{Valid: true, Int64: *days}
+	}
+	organization.MessageRetentionDays = toNull(messageDays)
+	organization.MuteRetentionDays = toNull(muteDays)
+	organization.LogRetentionDays = toNull(logDays)
+
+	// Save the changes
+	return s.DB.
+		Model(organization).
+		Select("message_retention_days", "mute_retention_days", "log_retention_days").
+		Updates(organization).
+		Error
+
+}
+
+// PurgeOrganization deletes all of the data in an organization that is older than its retention windows allow
+func (s *RetentionService) PurgeOrganization(organization *models.Organization) error {
+
+	// Purge chat messages older than the window
+	if cutoff, ok := retentionCutoff(organization.MessageRetentionDays); ok {
+		err := s.DB.
+			Where("organization_id = ?", organization.ID).
+			Where("created_date < ?", cutoff).
+			Delete(&models.ChatMessage{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Purge mutes that ended longer ago than the window. Mutes still in effect are kept
+	if cutoff, ok := retentionCutoff(organization.MuteRetentionDays); ok {
+		err := s.DB.
+			Where("organization_id = ?", organization.ID).
+			Where("deleted_date < ? OR until_date < ?", cutoff, cutoff).
+			Delete(&models.MutedUser{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Purge moderation events older than the window
+	if cutoff, ok := retentionCutoff(organization.LogRetentionDays); ok {
+		err := s.DB.
+			Where("organization_id = ?", organization.ID).
+			Where("created_date < ?", cutoff).
+			Delete(&models.ModerationEvent{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// PurgeExpiredData enforces the retention windows of every organization that has one
+func (s *RetentionService) PurgeExpiredData() error {
+
+	// Find all of the organizations with a retention window
+	var organizations []*models.Organization
+	err := s.DB.
+		Where("deleted_date IS NULL").
+		Where("message_retention_days IS NOT NULL OR mute_retention_days IS NOT NULL OR log_retention_days IS NOT NULL").
+		Find(&organizations).
+		Error
+	if err != nil {
+		return err
+	}
+
+	// Purge each of them
+	for _, organization := range organizations {
+		if err := s.PurgeOrganization(organization); err != nil {
+			return err
+		}
+	}
+	return nil
+
+}
+
+// RunPurgeJob enforces the retention windows on an interval. It blocks forever, so it should be run in its
+// own goroutine.
+func (s *RetentionService) RunPurgeJob(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.PurgeExpiredData(); err != nil {
+			fmt.Println("Error purging expired data: ", err.Error())
+		}
+	}
+}
@@ -16,25 +16,37 @@ type ChatUser struct {
 }
 
 type SocketsService struct {
-	Server      *socketio.Server
-	ChatService *ChatService
-	chatBuffers LiveChatBufferGroup
+	Server               *socketio.Server
+	IpResolver           *utils.IpResolver
+	AuthTokensService    *AuthTokensService
+	ChatService          *ChatService
+	ModerationService    *ModerationService
+	OrganizationsService *OrganizationsService
+	chatBuffers          LiveChatBufferGroup
+	heldMessages         HeldMessageQueue
+	reactions            reactionThrottle
+	powJoins             *utils.RateCounter
 }
 
 func socketRoomName(chatRoom *models.ChatRoom) string {
 	return fmt.Sprintf("chatroom_%s", chatRoom.Identifier)
 }
 
+// calculateMessageID calculates a unique identifier for a message. A random nonce is included so the same text
+// sent twice by the same user gets two distinct identifiers in the chat history.
 func calculateMessageID(msg *ChatMsg) string {
-	return utils.Sha256Hex(fmt.Sprintf("%s.%s", msg.User.Username, msg.Message))
+	return utils.Sha256Hex(fmt.Sprintf("%s.%s.%s", msg.User.Username, msg.Message, utils.RandHexStrInt64()))
 }
 
 func (s *SocketsService) Setup() {
 
+	// Count the joins from each IP address, to scale the proof-of-work challenges
+	s.powJoins = &utils.RateCounter{Window: powJoinWindow}
+
 	// Add handlers to the socket server
 	s.Server.OnConnect("/", func(conn socketio.Conn) error {
 		fmt.Println("client connected: ", conn.RemoteAddr().String())
-		return nil
+		return s.authenticateConn(conn)
 	})
 
 	// When a socket disconnects
@@ -48,6 +60,23 @@ func (s *SocketsService) Setup() {
 	s.Server.OnEvent("/", "chatroom.leave", s.OnChatRoomLeave)
 	s.Server.OnEvent("/", "chatroom.message", s.OnChatRoomMessage)
 	s.Server.OnEvent("/", "chatroom.revoke-message", s.OnChatRoomRevokeMessage)
+	s.Server.OnEvent("/", "chatroom.follow", s.OnChatRoomFollow)
+	s.Server.OnEvent("/", "chatroom.unfollow", s.OnChatRoomUnfollow)
+	s.Server.OnEvent("/", "chatroom.react", s.OnChatRoomReact)
+	s.Server.OnEvent("/", "chatroom.edit-message", s.OnChatRoomEditMessage)
+
+	// Register the moderator event handlers
+	s.Server.OnEvent("/", "mod.mute", s.OnModMute)
+	s.Server.OnEvent("/", "mod.timeout", s.OnModTimeout)
+	s.Server.OnEvent("/", "mod.unmute", s.OnModUnmute)
+	s.Server.OnEvent("/", "mod.revoke", s.OnModRevoke)
+	s.Server.OnEvent("/", "mod.purge-user", s.OnModPurgeUser)
+	s.Server.OnEvent("/", "mod.clear", s.OnModClear)
+	s.Server.OnEvent("/", "mod.approve", s.OnModApprove)
+	s.Server.OnEvent("/", "mod.reject", s.OnModReject)
+
+	// Register the studio namespace for moderators
+	s.setupStudio()
 
 }
 
@@ -62,7 +91,8 @@ func (s *SocketsService) Broadcast(room, event string, args ...interface{}) bool
 //====================================================================================================
 
 type ChatRoomJoinMsg struct {
-	ChatRoomIdentifier string `json:"chat_room_identifier"`
+	ChatRoomIdentifier string   `json:"chat_room_identifier"`
+	User               ChatUser `json:"user"`
 }
 
 func (s *SocketsService) OnChatRoomJoin(conn socketio.Conn, data ChatRoomJoinMsg) error {
@@ -76,23 +106,58 @@ func (s *SocketsService) OnChatRoomJoin(conn socketio.Conn, data ChatRoomJoinMsg
 		return errors.New("chat room not found")
 	}
 
-	// Join the room for the event
+	// Viewers who join with a username can't pose as someone protected in the organization
+	if len(data.User.Username) > 0 {
+		impersonation, err := s.ChatService.CheckImpersonation(
+			chatRoom.OrganizationID,
+			data.User.Username,
+			getConnAccount(conn),
+		)
+		if err != nil {
+			return err
+		}
+		if impersonation != nil && impersonation.Exact {
+			return errors.New("username is reserved")
+		}
+	}
+
+	// Join the room for the event. Signed in viewers chatting under their own display name also join the room for
+	// mentions of it right away, while everyone else only joins it once a message under the username is delivered.
 	conn.Join(socketRoomName(chatRoom))
+	account := getConnAccount(conn)
+	if account != nil && chatUserKey(account.DisplayName) == chatUserKey(data.User.Username) {
+		s.bindIdentity(conn, chatRoom, data.User.Username)
+	}
 
-	// Emit all the buffered messages to the new viewer, so they don't open the page to
-	// a completely empty live chat screen
+	// Emit all the buffered messages to the new viewer, along with their reactions so far, so they don't open the
+	// page to a completely empty live chat screen
 	bufMsgs := s.chatBuffers.CopyMessages(chatRoom.ID)
 	messagesSer := make([]map[string]interface{}, len(bufMsgs))
 	for i, msg := range bufMsgs {
-		messagesSer[i] = map[string]interface{}{
-			"id":        msg.ID,
-			"username":  msg.Message.User.Username,
-			"photo_url": msg.Message.User.PhotoUrl,
-			"message":   msg.Message.Message,
-		}
+		messagesSer[i] = serializeChatMsg(msg.ID, msg.Message)
+		messagesSer[i]["reactions"] = s.chatBuffers.ReactionCounts(chatRoom.ID, msg.ID)
 	}
 	conn.Emit("chat.messages", messagesSer)
 
+	// Emit the emoji the viewer can react to messages with
+	organization, err := s.OrganizationsService.GetOrganizationByID(chatRoom.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if organization != nil {
+		conn.Emit("chat.reaction-set", GetReactions(organization))
+	}
+
+	// Emit the pinned message, if there is one
+	if chatRoom.HasActivePin() {
+		conn.Emit("chat.pin", serializeChatPin(chatRoom))
+	}
+
+	// Challenge the viewer to prove their work, if the room requires it
+	if chatRoom.ProofOfWork {
+		s.issueProofOfWork(conn, chatRoom)
+	}
+
 	fmt.Println("joined stream: ", chatRoom.Identifier, conn.RemoteAddr().String())
 
 	return nil
@@ -119,8 +184,9 @@ func (s *SocketsService) OnChatRoomLeave(conn socketio.Conn, data ChatRoomLeaveM
 		return errors.New("chat room not found")
 	}
 
-	// Leave the room for the event
+	// Leave the room for the event, and the room for mentions of the viewer
 	conn.Leave(socketRoomName(chatRoom))
+	s.unbindIdentity(conn, chatRoom, "")
 
 	fmt.Println("left stream: ", chatRoom.Identifier, conn.RemoteAddr().String())
 
@@ -128,15 +194,90 @@ func (s *SocketsService) OnChatRoomLeave(conn socketio.Conn, data ChatRoomLeaveM
 
 }
 
+//====================================================================================================
+// chatroom.follow and chatroom.unfollow event handlers
+// Called when a viewer follows or unfollows the organization of a stream
+//====================================================================================================
+
+type ChatRoomFollowMsg struct {
+	ChatRoomIdentifier string   `json:"chat_room_identifier"`
+	User               ChatUser `json:"user"`
+}
+
+func (s *SocketsService) OnChatRoomFollow(conn socketio.Conn, data ChatRoomFollowMsg) error {
+	return s.setFollowing(conn, data, true)
+}
+
+func (s *SocketsService) OnChatRoomUnfollow(conn socketio.Conn, data ChatRoomFollowMsg) error {
+	return s.setFollowing(conn, data, false)
+}
+
+// setFollowing marks a viewer as following the organization of a chat room, or not. The follow is tied to the
+// account or IP address of the connection, so nobody else can unfollow it.
+func (s *SocketsService) setFollowing(conn socketio.Conn, data ChatRoomFollowMsg, following bool) error {
+
+	// Get the stream with the identifier
+	chatRoom, err := s.ChatService.GetChatRoomByIdentifier(data.ChatRoomIdentifier)
+	if err != nil {
+		return err
+	}
+	if chatRoom == nil {
+		return errors.New("chat room not found")
+	}
+
+	// Wrap the chat user info
+	chatUserInfo := ChatUserInfo{
+		Username:  data.User.Username,
+		IpAddress: s.IpResolver.GetIpAddress(conn.RemoteHeader(), conn.RemoteAddr()),
+		Account:   getConnAccount(conn),
+	}
+
+	// Update the viewer's profile
+	_, err = s.ChatService.SetChatUserFollowing(chatRoom.OrganizationID, &chatUserInfo, following)
+	return err
+
+}
+
 //====================================================================================================
 // chatroom.message event handler
 // Called when a viewer sends a message in the chat
 //====================================================================================================
 
+// ChatMsg is a message sent by a viewer, optionally in reply to another message. The reply snippet, mentions, badges
+// and fragments are worked out on the server when the message is received, so they are never read from the viewer.
 type ChatMsg struct {
-	ChatRoomIdentifier string   `json:"chat_room_identifier"`
-	Message            string   `json:"message"`
-	User               ChatUser `json:"user"`
+	ChatRoomIdentifier string                    `json:"chat_room_identifier"`
+	Message            string                    `json:"message"`
+	User               ChatUser                  `json:"user"`
+	PowSolution        string                    `json:"pow_solution"`
+	ReplyTo            string                    `json:"reply_to"`
+	Reply              *MessageReply             `json:"-"`
+	Mentions           []string                  `json:"-"`
+	Badges             []*models.BadgeAssignment `json:"-"`
+	Fragments          []*MessageFragment        `json:"-"`
+	Edited             bool                      `json:"-"`
+}
+
+func serializeChatMsg(msgID string, msg *ChatMsg) map[string]interface{} {
+	badgesSer := make([]map[string]interface{}, len(msg.Badges))
+	for i, badge := range msg.Badges {
+		badgesSer[i] = SerializeMessageBadge(badge)
+	}
+	fragmentsSer := make([]map[string]interface{}, len(msg.Fragments))
+	for i, fragment := range msg.Fragments {
+		fragmentsSer[i] = SerializeMessageFragment(fragment)
+	}
+	return map[string]interface{}{
+		"id":        msgID,
+		"username":  msg.User.Username,
+		"photo_url": msg.User.PhotoUrl,
+		"message":   msg.Message,
+		"fragments": fragmentsSer,
+		"mentions":  msg.Mentions,
+		"reply_to":  serializeMessageReply(msg.Reply),
+		"badges":    badgesSer,
+		"edited":    msg.Edited,
+	}
 }
 
 func (s *SocketsService) OnChatRoomMessage(conn socketio.Conn, data ChatMsg) error {
@@ -150,14 +291,28 @@ func (s *SocketsService) OnChatRoomMessage(conn socketio.Conn, data ChatMsg) err
 		return errors.New("chat room not found")
 	}
 
+	// Make sure the viewer has solved the proof-of-work challenge, if the room requires it
+	if err := s.checkProofOfWork(conn, chatRoom, data.PowSolution); err != nil {
+		return err
+	}
+
+	// Find the message being replied to, if any
+	if len(data.ReplyTo) > 0 {
+		data.Reply, err = s.getReplyParent(chatRoom, data.ReplyTo)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Wrap the chat user info
 	chatUserInfo := ChatUserInfo{
 		Username:  data.User.Username,
-		IpAddress: utils.GetIpAddress(conn.RemoteHeader(), conn.RemoteAddr()),
+		IpAddress: s.IpResolver.GetIpAddress(conn.RemoteHeader(), conn.RemoteAddr()),
+		Account:   getConnAccount(conn),
 	}
 
 	// Check if we can send the message
-	canSend, bannedWord, err := s.ChatService.CanSendMessage(
+	verdict, err := s.ChatService.CanSendMessage(
 		chatRoom,
 		&chatUserInfo,
 		data.Message,
@@ -165,31 +320,57 @@ func (s *SocketsService) OnChatRoomMessage(conn socketio.Conn, data ChatMsg) err
 	if err != nil {
 		return err
 	}
-	if !canSend {
 
-		// If we ran afoul of a banned word
-		if bannedWord != nil {
+	// Get the badges shown next to the sender's messages
+	data.Badges, err = s.ChatService.GetMessageBadges(chatRoom, &chatUserInfo)
+	if err != nil {
+		return err
+	}
 
-			// The date to ban until
-			var ban bool
-			var banUntil *time.Time
+	// Split the message into fragments, so clients don't have to parse it themselves
+	data.Fragments, err = s.ChatService.GetMessageFragments(chatRoom.OrganizationID, data.Message)
+	if err != nil {
+		return err
+	}
+	data.Mentions = messageMentions(data.Fragments)
 
-			// If there is a permanent ban
-			if bannedWord.PermanentBan {
-				ban = true
-			} else if bannedWord.TemporaryMuteSeconds.Valid {
-				ban = true
-				until := time.Now().Add(time.Second * time.Duration(bannedWord.TemporaryMuteSeconds.Int64))
-				banUntil = &until
-			}
+	// Calculate the message identifier
+	msgID := calculateMessageID(&data)
 
-			// If we're banning the user, initiate the ban
-			if ban {
-				if _, err := s.ChatService.MuteUser(chatRoom.OrganizationID, &chatUserInfo, banUntil); err != nil {
-					fmt.Println("Error muting user: ", err.Error())
-				}
-			}
+	// Send the message and its verdict to the moderators of the organization
+	go s.BroadcastStudioMessage(chatRoom, msgID, &chatUserInfo, &data, verdict)
+
+	// If the message is held for review, queue it for the moderators instead of sending it
+	if verdict.Reason == VerdictHeld {
+		s.holdMessage(conn, chatRoom, msgID, &chatUserInfo, &data, verdict)
+		return nil
+	}
 
+	if !verdict.Allowed {
+
+		// If the sender is shadow muted, show the message back to them alone so it looks like it was delivered
+		if verdict.Reason == VerdictShadowMuted {
+			conn.Emit("chat.messages", []map[string]interface{}{
+				serializeChatMsg(msgID, &data),
+			})
+		}
+
+		// If we ran afoul of a banned word, penalize the sender
+		if verdict.Reason == VerdictBannedWord {
+			s.penalizeBannedWord(chatRoom, &chatUserInfo, verdict.BannedWord, msgID)
+		}
+
+		// If the message broke a spam rule, penalize the sender
+		if verdict.SpamRule != nil {
+			s.penalizeSpam(chatRoom, &chatUserInfo, verdict.SpamRule, msgID)
+		}
+
+		// Tell the sender why the message was rejected, if they are meant to know
+		if reason := rejectionReason(verdict); len(reason) > 0 {
+			conn.Emit("chat.message-rejected", map[string]interface{}{
+				"id":     msgID,
+				"reason": reason,
+			})
 		}
 
 		// Return here to prevent sending the message
@@ -197,29 +378,138 @@ func (s *SocketsService) OnChatRoomMessage(conn socketio.Conn, data ChatMsg) err
 
 	}
 
-	// Calculate the message identifier
-	msgID := calculateMessageID(&data)
+	// Send the message to the room, and have mentions of the sender's username reach this connection from now on
+	s.deliverMessage(chatRoom, msgID, &chatUserInfo, &data)
+	s.bindIdentity(conn, chatRoom, data.User.Username)
+	return nil
+
+}
+
+// rejectionReason gets the reason code told to the sender of a rejected message, or an empty string if the sender
+// isn't told. Mutes and banned words are kept quiet, so they aren't easy to work around.
+func rejectionReason(verdict *MessageVerdict) string {
+	switch verdict.Reason {
+	case VerdictSpam:
+		return verdict.SpamRule.Heuristic
+	case VerdictLink, VerdictImpersonation, VerdictFollowersOnly, VerdictNewChatterWait, VerdictNewChatterLink:
+		return verdict.Reason
+	}
+	return ""
+}
+
+// deliverMessage broadcasts a message that passed moderation to a chat room, and keeps it in the buffer and the
+// chat history
+func (s *SocketsService) deliverMessage(chatRoom *models.ChatRoom, msgID string, user *ChatUserInfo, msg *ChatMsg) {
 
 	// Broadcast the message to the room
 	go s.Broadcast(
 		socketRoomName(chatRoom),
 		"chat.messages",
 		[]map[string]interface{}{
-			{
-				"id":        msgID,
-				"username":  data.User.Username,
-				"photo_url": data.User.PhotoUrl,
-				"message":   data.Message,
-			},
+			serializeChatMsg(msgID, msg),
 		},
 	)
 
+	// Let the mentioned chatters know
+	go s.notifyMentions(chatRoom, msgID, msg)
+
 	// Push the chat message to the buffer
 	// Do it in a goroutine because we don't care about the result and we don't want to block
 	// the socket handler just to do this task
-	go s.chatBuffers.PushMessage(chatRoom.ID, msgID, &data)
+	go s.chatBuffers.PushMessage(chatRoom.ID, msgID, user, msg)
 
-	return nil
+	// Save the message to the chat history
+	go func() {
+		if _, err := s.ChatService.SaveMessage(chatRoom, msgID, user, msg); err != nil {
+			fmt.Println("Error saving message: ", err.Error())
+		}
+	}()
+
+}
+
+// penalizeBannedWord mutes the sender of a message that contained a banned word, if the banned word carries a
+// mute with it
+func (s *SocketsService) penalizeBannedWord(
+	chatRoom *models.ChatRoom,
+	user *ChatUserInfo,
+	bannedWord *models.BannedWord,
+	msgID string,
+) {
+
+	// The date to ban until. A permanent ban has no end date, and a banned word with neither a permanent ban
+	// nor a temporary mute only blocks the message
+	var banUntil *time.Time
+	if !bannedWord.PermanentBan {
+		if !bannedWord.TemporaryMuteSeconds.Valid {
+			return
+		}
+		until := time.Now().Add(time.Second * time.Duration(bannedWord.TemporaryMuteSeconds.Int64))
+		banUntil = &until
+	}
+
+	// Initiate the ban
+	reason := fmt.Sprintf("banned word: %s", bannedWord.Word)
+	_, err := s.ChatService.MuteUser(chatRoom.OrganizationID, user, &MuteOptions{
+		UntilDate: banUntil,
+		Reason:    reason,
+	})
+	if err != nil {
+		fmt.Println("Error muting user: ", err.Error())
+		return
+	}
+
+	// Record the ban in the moderation log
+	action := models.ModerationActionMute
+	if banUntil != nil {
+		action = models.ModerationActionTimeout
+	}
+	s.recordModerationEvent(&ModerationEventInfo{
+		OrganizationID: chatRoom.OrganizationID,
+		ChatRoom:       chatRoom,
+		Actor:          AutomodActor(fmt.Sprintf("banned_word:%d", bannedWord.ID)),
+		Action:         action,
+		Target:         user,
+		Reason:         reason,
+		MessageID:      msgID,
+	})
+
+}
+
+// penalizeSpam times out the sender of a message that broke a spam rule, if the rule's action is a timeout
+func (s *SocketsService) penalizeSpam(
+	chatRoom *models.ChatRoom,
+	user *ChatUserInfo,
+	spamRule *models.SpamRule,
+	msgID string,
+) {
+
+	// Only timeouts carry a penalty beyond blocking the message
+	if spamRule.Action != models.SpamActionTimeout || !spamRule.TimeoutSeconds.Valid {
+		return
+	}
+
+	// Time out the sender
+	until := time.Now().Add(time.Second * time.Duration(spamRule.TimeoutSeconds.Int64))
+	reason := fmt.Sprintf("spam: %s", spamRule.Heuristic)
+	_, err := s.ChatService.MuteUser(chatRoom.OrganizationID, user, &MuteOptions{
+		UntilDate: &until,
+		Reason:    reason,
+	})
+	if err != nil {
+		fmt.Println("Error muting user: ", err.Error())
+		return
+	}
+
+	// Record the timeout in the moderation log
+	s.recordModerationEvent(&ModerationEventInfo{
+		OrganizationID: chatRoom.OrganizationID,
+		ChatRoom:       chatRoom,
+		Actor:          AutomodActor(fmt.Sprintf("spam_rule:%d", spamRule.ID)),
+		Action:         models.ModerationActionTimeout,
+		Target:         user,
+		Reason:         reason,
+		MessageID:      msgID,
+	})
 
 }
 
@@ -229,8 +519,9 @@ func (s *SocketsService) OnChatRoomMessage(conn socketio.Conn, data ChatMsg) err
 //====================================================================================================
 
 type ChatRevokeMsg struct {
-	ChatRoomIdentifier string `json:"chat_room_identifier"`
-	MessageID          string `json:"message_id"`
+	ChatRoomIdentifier string   `json:"chat_room_identifier"`
+	MessageID          string   `json:"message_id"`
+	User               ChatUser `json:"user"`
 }
 
 func (s *SocketsService) OnChatRoomRevokeMessage(conn socketio.Conn, data ChatRevokeMsg) error {
@@ -244,19 +535,280 @@ func (s *SocketsService) OnChatRoomRevokeMessage(conn socketio.Conn, data ChatRe
 		return errors.New("chat room not found")
 	}
 
+	// Wrap the chat user info
+	chatUserInfo := ChatUserInfo{
+		Username:  data.User.Username,
+		IpAddress: s.IpResolver.GetIpAddress(conn.RemoteHeader(), conn.RemoteAddr()),
+		Account:   getConnAccount(conn),
+	}
+
+	// Only the sender can revoke their own message
+	bufMsg := s.chatBuffers.GetMessage(chatRoom.ID, data.MessageID)
+	if bufMsg == nil || !bufMsg.SentFrom(&chatUserInfo) {
+		return errors.New("message not found")
+	}
+
+	// Revoke the message
+	s.RevokeMessage(chatRoom, data.MessageID)
+
+	// Record the revocation, attributed to the viewer who asked for it
+	sender := &ChatUserInfo{
+		Username:  bufMsg.Message.User.Username,
+		IpAddress: bufMsg.IpAddress,
+	}
+	s.recordModerationEvent(&ModerationEventInfo{
+		OrganizationID: chatRoom.OrganizationID,
+		ChatRoom:       chatRoom,
+		Actor:          ViewerActor(chatUserInfo.Username),
+		Action:         models.ModerationActionRevoke,
+		Target:         sender,
+		MessageID:      data.MessageID,
+	})
+
+	// Return without error
+	return nil
+
+}
+
+// getBufferedSender gets the user who sent a message that is still in the buffer of a chat room
+func (s *SocketsService) getBufferedSender(chatRoom *models.ChatRoom, msgID string) *ChatUserInfo {
+	bufMsg := s.chatBuffers.GetMessage(chatRoom.ID, msgID)
+	if bufMsg == nil {
+		return nil
+	}
+	return &ChatUserInfo{
+		Username:  bufMsg.Message.User.Username,
+		IpAddress: bufMsg.IpAddress,
+	}
+}
+
+// RevokeMessage removes a message from a chat room and notifies all of the viewers
+func (s *SocketsService) RevokeMessage(chatRoom *models.ChatRoom, msgID string) {
+
 	// Broadcast the deletion of the message to the room
 	go s.Broadcast(
 		socketRoomName(chatRoom),
 		"chat.revoke-message",
 		map[string]interface{}{
-			"id": data.MessageID,
+			"id": msgID,
 		},
 	)
 
 	// Revoke the message from the buffer
-	go s.chatBuffers.RevokeMessage(chatRoom.ID, data.MessageID)
+	go s.chatBuffers.RevokeMessage(chatRoom.ID, msgID)
 
-	// Return without error
+	// Remove the message from the chat history
+	go func() {
+		if err := s.ChatService.DeleteMessage(chatRoom, msgID); err != nil {
+			fmt.Println("Error deleting message: ", err.Error())
+		}
+	}()
+
+}
+
+// PurgeUserMessages removes all of the messages sent by a user from a chat room, both from the buffer and the
+// chat history, and notifies all of the viewers. The IDs of the removed messages are returned.
+func (s *SocketsService) PurgeUserMessages(chatRoom *models.ChatRoom, user *ChatUserInfo) ([]string, error) {
+
+	// Revoke the user's messages from the buffer
+	msgIDs := s.chatBuffers.RevokeUserMessages(chatRoom.ID, user)
+
+	// Remove the user's messages from the chat history
+	historyIDs, err := s.ChatService.DeleteUserMessages(chatRoom, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Combine the IDs from both, without any duplicates
+	seen := map[string]bool{}
+	for _, msgID := range msgIDs {
+		seen[msgID] = true
+	}
+	for _, msgID := range historyIDs {
+		if !seen[msgID] {
+			seen[msgID] = true
+			msgIDs = append(msgIDs, msgID)
+		}
+	}
+
+	// Broadcast the deletion of all the messages to the room at once
+	if len(msgIDs) > 0 {
+		go s.Broadcast(
+			socketRoomName(chatRoom),
+			"chat.revoke-messages",
+			map[string]interface{}{
+				"ids": msgIDs,
+			},
+		)
+	}
+
+	return msgIDs, nil
+
+}
+
+// PurgeOrganizationUserMessages removes all of the messages sent by a user from every chat room in an organization
+func (s *SocketsService) PurgeOrganizationUserMessages(organizationID uint64, user *ChatUserInfo) error {
+
+	// Get all of the chat rooms in the organization
+	chatRooms, err := s.ChatService.GetChatRoomsByOrganization(organizationID)
+	if err != nil {
+		return err
+	}
+
+	// Purge the user from each of them
+	for _, chatRoom := range chatRooms {
+		if _, err := s.PurgeUserMessages(chatRoom, user); err != nil {
+			return err
+		}
+	}
 	return nil
 
 }
+
+// ClearChatRoom removes every buffered message from a chat room, marks its chat history as cleared, and tells
+// all of the viewers to empty their view of the chat
+func (s *SocketsService) ClearChatRoom(chatRoom *models.ChatRoom) error {
+
+	// Clear the buffer
+	s.chatBuffers.ClearMessages(chatRoom.ID)
+
+	// Mark the chat history as cleared
+	if err := s.ChatService.ClearMessages(chatRoom); err != nil {
+		return err
+	}
+
+	// Broadcast the clear to the room
+	go s.Broadcast(
+		socketRoomName(chatRoom),
+		"chat.clear",
+		map[string]interface{}{},
+	)
+
+	return nil
+
+}
+
+//====================================================================================================
+// Pinned messages
+// Called by moderators to pin a message to the top of a chat room
+//====================================================================================================
+
+func serializeChatPin(chatRoom *models.ChatRoom) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         chatRoom.PinnedMessageID.String,
+		"username":   chatRoom.PinnedUsername.String,
+		"photo_url":  chatRoom.PinnedPhotoUrl.String,
+		"message":    chatRoom.PinnedMessage.String,
+		"until_date": utils.FlattenNullTimeSec(chatRoom.PinnedUntilDate),
+	}
+}
+
+// PinMessage pins a message to the top of a chat room. If messageID is provided, the message is taken from the
+// buffered messages in the room. Otherwise, the text is pinned as an announcement from the organization.
+func (s *SocketsService) PinMessage(
+	chatRoom *models.ChatRoom,
+	messageID string,
+	message string,
+	untilDate *time.Time,
+) error {
+
+	// Build the pin from either the buffered message or the new text
+	var pin ChatPin
+	if len(messageID) > 0 {
+		bufMsg := s.chatBuffers.GetMessage(chatRoom.ID, messageID)
+		if bufMsg == nil {
+			return errors.New("message not found")
+		}
+		pin = ChatPin{
+			MessageID: bufMsg.ID,
+			Username:  bufMsg.Message.User.Username,
+			PhotoUrl:  bufMsg.Message.User.PhotoUrl,
+			Message:   bufMsg.Message.Message,
+		}
+	} else {
+		if len(message) == 0 {
+			return errors.New("message cannot be empty")
+		}
+		organization, err := s.OrganizationsService.GetOrganizationByID(chatRoom.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if organization == nil {
+			return errors.New("organization not found")
+		}
+		pin = ChatPin{
+			Username: organization.Name,
+			Message:  message,
+		}
+		pin.MessageID = calculateMessageID(&ChatMsg{
+			Message: pin.Message,
+			User:    ChatUser{Username: pin.Username},
+		})
+	}
+
+	// Save the pin on the chat room
+	if err := s.ChatService.PinMessage(chatRoom, &pin, untilDate); err != nil {
+		return err
+	}
+
+	// Broadcast the pin to the room
+	go s.Broadcast(
+		socketRoomName(chatRoom),
+		"chat.pin",
+		serializeChatPin(chatRoom),
+	)
+
+	// If the pin expires, remove it when the time comes
+	if untilDate != nil {
+		time.AfterFunc(time.Until(*untilDate), func() {
+			s.expirePin(chatRoom.ID, pin.MessageID)
+		})
+	}
+
+	return nil
+
+}
+
+// UnpinMessage removes the pinned message from a chat room and notifies all of the viewers
+func (s *SocketsService) UnpinMessage(chatRoom *models.ChatRoom) error {
+
+	// Remove the pin from the chat room
+	if err := s.ChatService.UnpinMessage(chatRoom); err != nil {
+		return err
+	}
+
+	// Broadcast the removal to the room
+	go s.Broadcast(
+		socketRoomName(chatRoom),
+		"chat.unpin",
+		map[string]interface{}{},
+	)
+
+	return nil
+
+}
+
+// expirePin removes a pin from a chat room once it has expired, as long as it hasn't been replaced since
+func (s *SocketsService) expirePin(chatRoomID uint64, messageID string) {
+
+	// Get the latest state of the chat room
+	chatRoom, err := s.ChatService.GetChatRoomByID(chatRoomID)
+	if err != nil {
+		fmt.Println("Error expiring pin: ", err.Error())
+		return
+	}
+	if chatRoom == nil {
+		return
+	}
+
+	// If the pin was replaced or is still active, leave it alone
+	if chatRoom.PinnedMessageID.String != messageID || chatRoom.HasActivePin() {
+		return
+	}
+
+	// Remove the pin
+	if err := s.UnpinMessage(chatRoom); err != nil {
+		fmt.Println("Error expiring pin: ", err.Error())
+	}
+
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+//====================================================================================================
+// chatroom.edit-message event handler
+// Called when a chatter changes the text of a message they sent recently
+//====================================================================================================
+
+type ChatEditMsg struct {
+	ChatRoomIdentifier string   `json:"chat_room_identifier"`
+	MessageID          string   `json:"message_id"`
+	Message            string   `json:"message"`
+	User               ChatUser `json:"user"`
+}
+
+func (s *SocketsService) OnChatRoomEditMessage(conn socketio.Conn, data ChatEditMsg) error {
+
+	// Get the stream with the identifier
+	chatRoom, err := s.ChatService.GetChatRoomByIdentifier(data.ChatRoomIdentifier)
+	if err != nil {
+		return err
+	}
+	if chatRoom == nil {
+		return errors.New("chat room not found")
+	}
+	if chatRoom.EditWindowSeconds <= 0 {
+		return errors.New("editing is turned off")
+	}
+
+	// Wrap the chat user info
+	chatUserInfo := ChatUserInfo{
+		Username:  data.User.Username,
+		IpAddress: s.IpResolver.GetIpAddress(conn.RemoteHeader(), conn.RemoteAddr()),
+		Account:   getConnAccount(conn),
+	}
+
+	// Only the sender can edit a message, and only while it's recent
+	bufMsg := s.chatBuffers.GetMessage(chatRoom.ID, data.MessageID)
+	if bufMsg == nil || !bufMsg.SentFrom(&chatUserInfo) {
+		return errors.New("message not found")
+	}
+	if time.Since(bufMsg.SentDate) > time.Duration(chatRoom.EditWindowSeconds)*time.Second {
+		return errors.New("message can no longer be edited")
+	}
+
+	// Check if we can send the new text
+	verdict, err := s.ChatService.CanSendMessage(
+		chatRoom,
+		&chatUserInfo,
+		data.Message,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Build the edited message, keeping everything but the text from the original
+	edited := *bufMsg.Message
+	edited.Message = data.Message
+	edited.Edited = true
+	edited.Fragments, err = s.ChatService.GetMessageFragments(chatRoom.OrganizationID, data.Message)
+	if err != nil {
+		return err
+	}
+	edited.Mentions = messageMentions(edited.Fragments)
+
+	// Send the edit and its verdict to the moderators of the organization
+	go s.BroadcastStudioMessage(chatRoom, data.MessageID, &chatUserInfo, &edited, verdict)
+
+	// Edits can't wait for review, since the original is already out
+	if verdict.Reason == VerdictHeld {
+		return errors.New("edit needs review")
+	}
+
+	if !verdict.Allowed {
+
+		// If the sender is shadow muted, show the edit back to them alone so it looks like it went through
+		if verdict.Reason == VerdictShadowMuted {
+			conn.Emit("chat.edit-message", serializeChatMsg(data.MessageID, &edited))
+		}
+
+		// If we ran afoul of a banned word, penalize the sender
+		if verdict.Reason == VerdictBannedWord {
+			s.penalizeBannedWord(chatRoom, &chatUserInfo, verdict.BannedWord, data.MessageID)
+		}
+
+		// If the edit broke a spam rule, penalize the sender
+		if verdict.SpamRule != nil {
+			s.penalizeSpam(chatRoom, &chatUserInfo, verdict.SpamRule, data.MessageID)
+		}
+
+		// Tell the sender why the edit was rejected, if they are meant to know
+		if reason := rejectionReason(verdict); len(reason) > 0 {
+			conn.Emit("chat.edit-rejected", map[string]interface{}{
+				"id":     data.MessageID,
+				"reason": reason,
+			})
+		}
+
+		// Return here to keep the original message
+		return nil
+
+	}
+
+	// Replace the message in the buffer, unless it was removed in the meantime
+	if !s.chatBuffers.EditMessage(chatRoom.ID, data.MessageID, &edited) {
+		return errors.New("message not found")
+	}
+
+	// Broadcast the edit to the room
+	go s.Broadcast(
+		socketRoomName(chatRoom),
+		"chat.edit-message",
+		serializeChatMsg(data.MessageID, &edited),
+	)
+
+	// Update the message in the chat history
+	go func() {
+		if err := s.ChatService.EditMessage(chatRoom, data.MessageID, data.Message); err != nil {
+			fmt.Println("Error editing message: ", err.Error())
+		}
+	}()
+	return nil
+
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// maxHeldMessages is the most messages held in a single chat room. Once full, the oldest held message is dropped
+// to make room for the next one.
+const maxHeldMessages = 200
+
+// HeldMessage is a message waiting for a moderator to approve or reject it
+type HeldMessage struct {
+	ID                 string
+	OrganizationID     uint64
+	ChatRoomID         uint64
+	ChatRoomIdentifier string
+	User               ChatUserInfo
+	Message            *ChatMsg
+	HoldReason         string
+	FlaggedWord        *models.BannedWord
+	SpamRule           *models.SpamRule
+	LinkDomain         string
+	Impersonation      *ImpersonationMatch
+	HeldDate           time.Time
+}
+
+// SerializeHeldMessage converts a held message into a map for the studio
+func SerializeHeldMessage(held *HeldMessage) map[string]interface{} {
+	var flaggedWord interface{}
+	if held.FlaggedWord != nil {
+		flaggedWord = map[string]interface{}{
+			"id":   held.FlaggedWord.ID,
+			"word": held.FlaggedWord.Word,
+		}
+	}
+	var spamRule interface{}
+	if held.SpamRule != nil {
+		spamRule = SerializeSpamRule(held.SpamRule)
+	}
+	var impersonation interface{}
+	if held.Impersonation != nil {
+		impersonation = map[string]interface{}{
+			"name":  held.Impersonation.Name,
+			"exact": held.Impersonation.Exact,
+		}
+	}
+	return map[string]interface{}{
+		"id":                   held.ID,
+		"chat_room_identifier": held.ChatRoomIdentifier,
+		"username":             held.User.Username,
+		"ip_address":           held.User.IpAddress,
+		"photo_url":            held.Message.User.PhotoUrl,
+		"message":              held.Message.Message,
+		"hold_reason":          held.HoldReason,
+		"flagged_word":         flaggedWord,
+		"spam_rule":            spamRule,
+		"link_domain":          held.LinkDomain,
+		"impersonation":        impersonation,
+		"held_date":            held.HeldDate.UTC().Unix(),
+	}
+}
+
+// HeldMessageQueue holds the messages waiting for review in each chat room. Like the message buffers, it only
+// lives in memory, so held messages keep the sender's real IP address for moderators to act on.
+type HeldMessageQueue struct {
+	rooms    map[uint64][]*HeldMessage
+	roomsMut sync.Mutex
+}
+
+// Push adds a message to the queue of its chat room. If the queue was full, the dropped message is returned.
+func (q *HeldMessageQueue) Push(held *HeldMessage) *HeldMessage {
+
+	// Lock on the queues
+	q.roomsMut.Lock()
+	defer q.roomsMut.Unlock()
+
+	// If the queues map is nil, create it
+	if q.rooms == nil {
+		q.rooms = map[uint64][]*HeldMessage{}
+	}
+
+	// Add the message, dropping the oldest one if the queue is full
+	var dropped *HeldMessage
+	queue := q.rooms[held.ChatRoomID]
+	if len(queue) >= maxHeldMessages {
+		dropped = queue[0]
+		queue = queue[1:]
+	}
+	q.rooms[held.ChatRoomID] = append(queue, held)
+	return dropped
+
+}
+
+// Take removes a message from the queue of a chat room and returns it, or nil if it isn't held
+func (q *HeldMessageQueue) Take(chatRoomID uint64, msgID string) *HeldMessage {
+
+	// Lock on the queues
+	q.roomsMut.Lock()
+	defer q.roomsMut.Unlock()
+
+	// Find the message in the queue
+	queue := q.rooms[chatRoomID]
+	for i, held := range queue {
+		if held.ID == msgID {
+			q.rooms[chatRoomID] = append(queue[:i:i], queue[i+1:]...)
+			return held
+		}
+	}
+	return nil
+
+}
+
+// ListOrganization gets all of the held messages in the chat rooms of an organization, oldest first
+func (q *HeldMessageQueue) ListOrganization(organizationID uint64) []*HeldMessage {
+
+	// Lock on the queues
+	q.roomsMut.Lock()
+	defer q.roomsMut.Unlock()
+
+	// Copy the messages from every room in the organization
+	messages := []*HeldMessage{}
+	for _, queue := range q.rooms {
+		for _, held := range queue {
+			if held.OrganizationID == organizationID {
+				messages = append(messages, held)
+			}
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].HeldDate.Before(messages[j].HeldDate)
+	})
+	return messages
+
+}
+
+//====================================================================================================
+// Holding messages for review
+//====================================================================================================
+
+// holdMessage puts a message in the review queue of its chat room, and lets the sender and the moderators know
+func (s *SocketsService) holdMessage(
+	conn socketio.Conn,
+	chatRoom *models.ChatRoom,
+	msgID string,
+	user *ChatUserInfo,
+	msg *ChatMsg,
+	verdict *MessageVerdict,
+) {
+
+	// Add the message to the queue
+	held := &HeldMessage{
+		ID:                 msgID,
+		OrganizationID:     chatRoom.OrganizationID,
+		ChatRoomID:         chatRoom.ID,
+		ChatRoomIdentifier: chatRoom.Identifier,
+		User:               *user,
+		Message:            msg,
+		HoldReason:         verdict.HoldReason,
+		FlaggedWord:        verdict.BannedWord,
+		SpamRule:           verdict.SpamRule,
+		LinkDomain:         verdict.LinkDomain,
+		Impersonation:      verdict.Impersonation,
+		HeldDate:           time.Now(),
+	}
+	dropped := s.heldMessages.Push(held)
+
+	// Let the sender know their message is waiting for review
+	conn.Emit("chat.message-held", serializeChatMsg(msgID, msg))
+
+	// Add the message to the moderators' queue, removing any message that was dropped to make room
+	s.BroadcastStudio(
+		chatRoom.OrganizationID,
+		"studio.held-messages",
+		[]map[string]interface{}{
+			SerializeHeldMessage(held),
+		},
+	)
+	if dropped != nil {
+		s.broadcastHeldResolved(dropped, "dropped")
+	}
+
+}
+
+// broadcastHeldResolved tells the moderators that a held message has left the queue
+func (s *SocketsService) broadcastHeldResolved(held *HeldMessage, status string) {
+	s.BroadcastStudio(
+		held.OrganizationID,
+		"studio.held-resolved",
+		map[string]interface{}{
+			"id":                   held.ID,
+			"chat_room_identifier": held.ChatRoomIdentifier,
+			"status":               status,
+		},
+	)
+}
+
+// GetHeldMessages gets all of the messages waiting for review in an organization
+func (s *SocketsService) GetHeldMessages(organizationID uint64) []*HeldMessage {
+	return s.heldMessages.ListOrganization(organizationID)
+}
+
+// ApproveHeldMessage removes a message from the review queue and sends it to the chat room as normal
+func (s *SocketsService) ApproveHeldMessage(chatRoom *models.ChatRoom, msgID string, actor *ModerationActor) error {
+
+	// Take the message from the queue
+	held := s.heldMessages.Take(chatRoom.ID, msgID)
+	if held == nil {
+		return errors.New("held message not found")
+	}
+
+	// Send the message to the chat room
+	s.deliverMessage(chatRoom, held.ID, &held.User, held.Message)
+
+	// Record the approval and update the moderators' queue
+	s.recordModerationEvent(&ModerationEventInfo{
+		OrganizationID: chatRoom.OrganizationID,
+		ChatRoom:       chatRoom,
+		Actor:          actor,
+		Action:         models.ModerationActionApprove,
+		Target:         &held.User,
+		MessageID:      held.ID,
+	})
+	s.broadcastHeldResolved(held, "approved")
+	return nil
+
+}
+
+// RejectHeldMessage removes a message from the review queue without sending it
+func (s *SocketsService) RejectHeldMessage(
+	chatRoom *models.ChatRoom,
+	msgID string,
+	actor *ModerationActor,
+	reason string,
+) error {
+
+	// Take the message from the queue
+	held := s.heldMessages.Take(chatRoom.ID, msgID)
+	if held == nil {
+		return errors.New("held message not found")
+	}
+
+	// Record the rejection and update the moderators' queue
+	s.recordModerationEvent(&ModerationEventInfo{
+		OrganizationID: chatRoom.OrganizationID,
+		ChatRoom:       chatRoom,
+		Actor:          actor,
+		Action:         models.ModerationActionReject,
+		Target:         &held.User,
+		Reason:         reason,
+		MessageID:      held.ID,
+	})
+	s.broadcastHeldResolved(held, "rejected")
+	return nil
+
+}
+
+//====================================================================================================
+// mod.approve and mod.reject event handlers
+// Called when a moderator reviews a held message from their socket connection
+//====================================================================================================
+
+func (s *SocketsService) OnModApprove(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+
+	// Approve the message
+	return s.ApproveHeldMessage(chatRoom, data.MessageID, AccountActor(getConnAccount(conn)))
+
+}
+
+func (s *SocketsService) OnModReject(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+
+	// Reject the message
+	return s.RejectHeldMessage(chatRoom, data.MessageID, AccountActor(getConnAccount(conn)), data.Reason)
+
+}
+
+// emitHeldMessages sends the current review queue of an organization to a moderator who just joined the studio
+func (s *SocketsService) emitHeldMessages(conn socketio.Conn, organizationID uint64) {
+	heldMessages := s.GetHeldMessages(organizationID)
+	messagesSer := make([]map[string]interface{}, len(heldMessages))
+	for i, held := range heldMessages {
+		messagesSer[i] = SerializeHeldMessage(held)
+	}
+	conn.Emit("studio.held-messages", messagesSer)
+}
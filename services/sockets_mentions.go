@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+const (
+	// maxReplySnippet is the most characters of the parent message embedded in a reply
+	maxReplySnippet = 100
+
+	// maxMentions is the most chatters a single message can notify with mentions
+	maxMentions = 10
+)
+
+// MessageReply is a snippet of the message that a chat message replies to
+type MessageReply struct {
+	ID       string
+	Username string
+	Message  string
+}
+
+// serializeMessageReply converts a reply snippet into a map, or nil if the message isn't a reply
+func serializeMessageReply(reply *MessageReply) interface{} {
+	if reply == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"id":       reply.ID,
+		"username": reply.Username,
+		"message":  reply.Message,
+	}
+}
+
+// replySnippet shortens the text of a parent message to embed it in a reply
+func replySnippet(message string) string {
+	runes := []rune(message)
+	if len(runes) <= maxReplySnippet {
+		return message
+	}
+	return strings.TrimSpace(string(runes[:maxReplySnippet])) + "…"
+}
+
+// getReplyParent finds the message a reply is for, first in the buffer of the chat room and then in the chat
+// history. An error is returned if the message doesn't exist, or was removed.
+func (s *SocketsService) getReplyParent(chatRoom *models.ChatRoom, msgID string) (*MessageReply, error) {
+
+	// Check the buffer, which has the recent messages
+	if bufMsg := s.chatBuffers.GetMessage(chatRoom.ID, msgID); bufMsg != nil {
+		return &MessageReply{
+			ID:       msgID,
+			Username: bufMsg.Message.User.Username,
+			Message:  replySnippet(bufMsg.Message.Message),
+		}, nil
+	}
+
+	// Check the chat history for older messages
+	chatMessage, err := s.ChatService.GetMessage(chatRoom, msgID)
+	if err != nil {
+		return nil, err
+	}
+	if chatMessage == nil {
+		return nil, errors.New("reply parent not found")
+	}
+	return &MessageReply{
+		ID:       msgID,
+		Username: chatMessage.Username,
+		Message:  replySnippet(chatMessage.Message),
+	}, nil
+
+}
+
+// messageMentions gets the usernames mentioned in the fragments of a message, each only once
+func messageMentions(fragments []*MessageFragment) []string {
+	mentions := []string{}
+	seen := map[string]bool{}
+	for _, fragment := range fragments {
+		if fragment.Type != utils.FragmentMention || seen[chatUserKey(fragment.Value)] {
+			continue
+		}
+		seen[chatUserKey(fragment.Value)] = true
+		mentions = append(mentions, fragment.Value)
+	}
+	return mentions
+}
+
+//====================================================================================================
+// Identity rooms
+// Each viewer's connection joins a room for the username they chat under, so mentions reach only them
+//====================================================================================================
+
+// identityRoomPrefix gets the prefix of the identity rooms in a chat room
+func identityRoomPrefix(chatRoom *models.ChatRoom) string {
+	return fmt.Sprintf("mention_%d_", chatRoom.ID)
+}
+
+// identityRoomName gets the name of the room for the connections chatting under a username in a chat room
+func identityRoomName(chatRoom *models.ChatRoom, username string) string {
+	return identityRoomPrefix(chatRoom) + chatUserKey(username)
+}
+
+// bindIdentity puts a connection in the identity room of the username it chats under, taking it out of the room
+// of any username it used before
+func (s *SocketsService) bindIdentity(conn socketio.Conn, chatRoom *models.ChatRoom, username string) {
+	if len(chatUserKey(username)) == 0 {
+		return
+	}
+	room := identityRoomName(chatRoom, username)
+	s.unbindIdentity(conn, chatRoom, room)
+	conn.Join(room)
+}
+
+// unbindIdentity takes a connection out of its identity rooms in a chat room, except for the room to keep
+func (s *SocketsService) unbindIdentity(conn socketio.Conn, chatRoom *models.ChatRoom, keep string) {
+	prefix := identityRoomPrefix(chatRoom)
+	for _, room := range conn.Rooms() {
+		if strings.HasPrefix(room, prefix) && room != keep {
+			conn.Leave(room)
+		}
+	}
+}
+
+// notifyMentions sends a delivered message to the chatters it mentions, and to the author of the message it replies
+// to. The sender is never notified about their own message.
+func (s *SocketsService) notifyMentions(chatRoom *models.ChatRoom, msgID string, msg *ChatMsg) {
+
+	// Collect the chatters to notify
+	usernames := append([]string{}, msg.Mentions...)
+	if msg.Reply != nil {
+		usernames = append(usernames, msg.Reply.Username)
+	}
+
+	// Notify each of them once
+	notified := map[string]bool{chatUserKey(msg.User.Username): true}
+	for _, username := range usernames {
+		key := chatUserKey(username)
+		if len(key) == 0 || notified[key] || len(notified) > maxMentions {
+			continue
+		}
+		notified[key] = true
+		s.Broadcast(
+			identityRoomName(chatRoom, username),
+			"chat.mention",
+			map[string]interface{}{
+				"chat_room_identifier": chatRoom.Identifier,
+				"message":              serializeChatMsg(msgID, msg),
+			},
+		)
+	}
+
+}
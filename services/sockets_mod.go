@@ -0,0 +1,354 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// socketSession is the context stored on every socket connection. Account is nil for anonymous viewers.
+type socketSession struct {
+	Account          *models.Account
+	powChallenges    map[uint64]*powChallenge
+	powChallengesMut sync.Mutex
+}
+
+// authenticateConn checks for an auth token on a new socket connection, and attaches the account to the
+// connection if one is found. Connections without a token are anonymous viewers.
+func (s *SocketsService) authenticateConn(conn socketio.Conn) error {
+
+	// Every connection gets a session, even if it stays anonymous
+	session := &socketSession{}
+	conn.SetContext(session)
+
+	// Get the token from the query string, falling back to the authorization header
+	url := conn.URL()
+	token := strings.TrimSpace(url.Query().Get("token"))
+	if len(token) == 0 {
+		authHeader := strings.TrimSpace(conn.RemoteHeader().Get("Authorization"))
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			token = strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		}
+	}
+
+	// If there is no token, the connection is an anonymous viewer
+	if len(token) == 0 {
+		return nil
+	}
+
+	// Find the account of the token
+	account, err := s.AuthTokensService.GetAccountForToken(token)
+	if err != nil || account == nil {
+		return errors.New("authentication failed")
+	}
+
+	// Attach the account to the connection
+	session.Account = account
+	return nil
+
+}
+
+// getConnSession gets the session (or nil) attached to a socket connection
+func getConnSession(conn socketio.Conn) *socketSession {
+	session, ok := conn.Context().(*socketSession)
+	if !ok {
+		return nil
+	}
+	return session
+}
+
+// getConnAccount gets the account (or nil) attached to a socket connection
+func getConnAccount(conn socketio.Conn) *models.Account {
+	session := getConnSession(conn)
+	if session == nil {
+		return nil
+	}
+	return session.Account
+}
+
+// recordModerationEvent records a moderation action in the log. Failures are only logged, since the action itself
+// has already been taken by the time it is recorded.
+func (s *SocketsService) recordModerationEvent(info *ModerationEventInfo) {
+	if _, err := s.ModerationService.RecordEvent(info); err != nil {
+		fmt.Println("Error recording moderation event: ", err.Error())
+	}
+}
+
+// recordModAction records an action taken by the moderator on a socket connection
+func (s *SocketsService) recordModAction(
+	conn socketio.Conn,
+	chatRoom *models.ChatRoom,
+	action string,
+	user *ChatUserInfo,
+	data *ModActionMsg,
+) {
+	s.recordModerationEvent(&ModerationEventInfo{
+		OrganizationID: chatRoom.OrganizationID,
+		ChatRoom:       chatRoom,
+		Actor:          AccountActor(getConnAccount(conn)),
+		Action:         action,
+		Target:         user,
+		Reason:         data.Reason,
+		MessageID:      data.MessageID,
+	})
+}
+
+//====================================================================================================
+// mod.* event handlers
+// Called when a moderator takes action on a chat room from their socket connection
+//====================================================================================================
+
+type ModActionMsg struct {
+	ChatRoomIdentifier string       `json:"chat_room_identifier"`
+	MessageID          string       `json:"message_id"`
+	User               ChatUserInfo `json:"user"`
+	DurationSeconds    int64        `json:"duration_seconds"`
+	Purge              bool         `json:"purge"`
+	Reason             string       `json:"reason"`
+	RoomOnly           bool         `json:"room_only"`
+	Shadow             bool         `json:"shadow"`
+	GroupIpv6          bool         `json:"group_ipv6"`
+}
+
+// getModeratedChatRoom gets the chat room for a moderator action, making sure the account on the connection
+// is allowed to moderate it
+func (s *SocketsService) getModeratedChatRoom(conn socketio.Conn, data *ModActionMsg) (*models.ChatRoom, error) {
+
+	// Get the account on the connection
+	account := getConnAccount(conn)
+	if account == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	// Get the stream with the identifier
+	chatRoom, err := s.ChatService.GetChatRoomByIdentifier(data.ChatRoomIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if chatRoom == nil {
+		return nil, errors.New("chat room not found")
+	}
+
+	// Check the account's role in the organization
+	canModerate, err := s.OrganizationsService.CanAccountModerate(account, chatRoom.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if !canModerate {
+		return nil, errors.New("not allowed to moderate this chat room")
+	}
+
+	return chatRoom, nil
+
+}
+
+// getModTargetUser gets the user targeted by a moderator action. If a message ID is provided, the user is
+// the sender of that buffered message, which lets moderators target IP addresses they can't see.
+func (s *SocketsService) getModTargetUser(chatRoom *models.ChatRoom, data *ModActionMsg) (*ChatUserInfo, error) {
+
+	// If there is a message, use its sender
+	if len(data.MessageID) > 0 {
+		user := s.getBufferedSender(chatRoom, data.MessageID)
+		if user == nil {
+			return nil, errors.New("message not found")
+		}
+		return user, nil
+	}
+
+	// Otherwise use the user provided, making sure the IP address is valid
+	if len(data.User.Username) == 0 && len(data.User.IpAddress) == 0 {
+		return nil, errors.New("no user specified")
+	}
+	if len(data.User.IpAddress) > 0 {
+		if _, err := utils.ParseIpRange(data.User.IpAddress); err != nil {
+			return nil, err
+		}
+	}
+	return &data.User, nil
+
+}
+
+// muteFromModAction mutes the user targeted by a moderator action, either in the whole organization or only in
+// the chat room if requested, and purges their earlier messages if requested
+func (s *SocketsService) muteFromModAction(
+	conn socketio.Conn,
+	chatRoom *models.ChatRoom,
+	user *ChatUserInfo,
+	data *ModActionMsg,
+	action string,
+	untilDate *time.Time,
+) error {
+
+	// Normalize the IP address, so the purge covers the same range as the mute
+	if len(user.IpAddress) > 0 {
+		ipAddress, err := NormalizeMuteIpAddress(user.IpAddress, data.GroupIpv6)
+		if err != nil {
+			return err
+		}
+		user = &ChatUserInfo{
+			Username:  user.Username,
+			IpAddress: ipAddress,
+		}
+	}
+
+	// Make sure the user's messages can be found, before muting them, if they are to be purged
+	if data.Purge {
+		if err := s.ChatService.ValidatePurge(user); err != nil {
+			return err
+		}
+	}
+
+	// Create the options for the mute
+	opts := MuteOptions{
+		UntilDate: untilDate,
+		Reason:    data.Reason,
+		Shadow:    data.Shadow,
+		GroupIpv6: data.GroupIpv6,
+	}
+	if data.RoomOnly {
+		opts.ChatRoom = chatRoom
+	}
+
+	// Mute the user
+	if _, err := s.ChatService.MuteUser(chatRoom.OrganizationID, user, &opts); err != nil {
+		return err
+	}
+	s.recordModAction(conn, chatRoom, action, user, data)
+
+	// Remove the user's earlier messages if requested
+	if !data.Purge {
+		return nil
+	}
+	if data.RoomOnly {
+		_, err := s.PurgeUserMessages(chatRoom, user)
+		return err
+	}
+	return s.PurgeOrganizationUserMessages(chatRoom.OrganizationID, user)
+
+}
+
+func (s *SocketsService) OnModMute(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room and the user to mute
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+	user, err := s.getModTargetUser(chatRoom, &data)
+	if err != nil {
+		return err
+	}
+
+	// Mute the user indefinitely
+	return s.muteFromModAction(conn, chatRoom, user, &data, models.ModerationActionMute, nil)
+
+}
+
+func (s *SocketsService) OnModTimeout(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room and the user to time out
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+	user, err := s.getModTargetUser(chatRoom, &data)
+	if err != nil {
+		return err
+	}
+
+	// Timeouts need a duration
+	if data.DurationSeconds <= 0 {
+		return errors.New("timeout duration must be positive")
+	}
+
+	// Mute the user until the timeout ends
+	until := time.Now().Add(time.Second * time.Duration(data.DurationSeconds))
+	return s.muteFromModAction(conn, chatRoom, user, &data, models.ModerationActionTimeout, &until)
+
+}
+
+func (s *SocketsService) OnModUnmute(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room and the user to unmute
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+	user, err := s.getModTargetUser(chatRoom, &data)
+	if err != nil {
+		return err
+	}
+
+	// Unmute the user
+	if err := s.ChatService.UnmuteUser(chatRoom.OrganizationID, user, false); err != nil {
+		return err
+	}
+	s.recordModAction(conn, chatRoom, models.ModerationActionUnmute, user, &data)
+	return nil
+
+}
+
+func (s *SocketsService) OnModRevoke(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+	if len(data.MessageID) == 0 {
+		return errors.New("no message specified")
+	}
+
+	// Find the sender of the message, so the log shows whose message was revoked
+	user := s.getBufferedSender(chatRoom, data.MessageID)
+
+	// Revoke the message
+	s.RevokeMessage(chatRoom, data.MessageID)
+	s.recordModAction(conn, chatRoom, models.ModerationActionRevoke, user, &data)
+	return nil
+
+}
+
+func (s *SocketsService) OnModPurgeUser(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room and the user to purge
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+	user, err := s.getModTargetUser(chatRoom, &data)
+	if err != nil {
+		return err
+	}
+
+	// Purge all of the user's messages
+	if _, err := s.PurgeUserMessages(chatRoom, user); err != nil {
+		return err
+	}
+	s.recordModAction(conn, chatRoom, models.ModerationActionPurge, user, &data)
+	return nil
+
+}
+
+func (s *SocketsService) OnModClear(conn socketio.Conn, data ModActionMsg) error {
+
+	// Get the chat room
+	chatRoom, err := s.getModeratedChatRoom(conn, &data)
+	if err != nil {
+		return err
+	}
+
+	// Clear the chat room
+	if err := s.ClearChatRoom(chatRoom); err != nil {
+		return err
+	}
+	s.recordModAction(conn, chatRoom, models.ModerationActionClear, nil, &data)
+	return nil
+
+}
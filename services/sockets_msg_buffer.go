@@ -1,10 +1,23 @@
 package services
 
-import "sync"
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/utils"
+)
 
 type wrappedMsg struct {
-	ID      string
-	Message *ChatMsg
+	ID        string
+	IpAddress string
+	AccountID uint64
+	SentDate  time.Time
+	Message   *ChatMsg
+
+	// reactions holds the identities that reacted to the message with each emoji. It is only touched while the
+	// buffer group is locked.
+	reactions map[string]map[string]bool
 }
 
 type LiveChatMessageBuffer struct {
@@ -12,12 +25,17 @@ type LiveChatMessageBuffer struct {
 	items     []*wrappedMsg
 }
 
-func (buf *LiveChatMessageBuffer) Push(msgID string, msg *ChatMsg) {
+func (buf *LiveChatMessageBuffer) Push(msgID string, user *ChatUserInfo, msg *ChatMsg) {
 
 	// Create the wrapped message instance
 	wmsg := &wrappedMsg{
-		ID:      msgID,
-		Message: msg,
+		ID:        msgID,
+		IpAddress: user.IpAddress,
+		SentDate:  time.Now(),
+		Message:   msg,
+	}
+	if user.Account != nil {
+		wmsg.AccountID = user.Account.ID
 	}
 
 	// If there is still room under the max, add it
@@ -59,6 +77,39 @@ func (buf *LiveChatMessageBuffer) Revoke(msgID string) {
 
 }
 
+// RevokeUser removes all of the messages sent by a user, matched by either username or IP address. The IDs
+// of the removed messages are returned.
+func (buf *LiveChatMessageBuffer) RevokeUser(user *ChatUserInfo) []string {
+
+	// Create the new slice for the items, and the slice of removed IDs
+	items := []*wrappedMsg{}
+	revoked := []string{}
+
+	// Loop through the buffer
+	for _, msg := range buf.items {
+
+		// If the item was sent by the user
+		if msg.SentBy(user) {
+			revoked = append(revoked, msg.ID)
+			continue
+		}
+
+		// Add it to the new items
+		items = append(items, msg)
+
+	}
+
+	// Update the items slice
+	buf.items = items
+	return revoked
+
+}
+
+// Clear removes every message from the buffer
+func (buf *LiveChatMessageBuffer) Clear() {
+	buf.items = nil
+}
+
 func (buf *LiveChatMessageBuffer) GetCopy() []*wrappedMsg {
 
 	// Create the new slice for elements
@@ -74,12 +125,35 @@ func (buf *LiveChatMessageBuffer) GetCopy() []*wrappedMsg {
 
 }
 
+// SentBy checks if the message was sent by a user, matched by either username or IP address. The IP address of
+// the user can also be a CIDR range
+func (msg *wrappedMsg) SentBy(user *ChatUserInfo) bool {
+	if len(user.Username) > 0 && strings.EqualFold(msg.Message.User.Username, user.Username) {
+		return true
+	}
+	if len(user.IpAddress) > 0 && utils.IpInRange(user.IpAddress, msg.IpAddress) {
+		return true
+	}
+	return false
+}
+
+// SentFrom checks if the message was sent by exactly this chatter, so they can change it. Messages sent while signed
+// in have to come from the same account, and others from the same username and IP address.
+func (msg *wrappedMsg) SentFrom(user *ChatUserInfo) bool {
+	if msg.AccountID != 0 {
+		return user.Account != nil && user.Account.ID == msg.AccountID
+	}
+	return len(user.Username) > 0 &&
+		strings.EqualFold(msg.Message.User.Username, user.Username) &&
+		msg.IpAddress == user.IpAddress
+}
+
 type LiveChatBufferGroup struct {
 	streamChatBuffers    map[uint64]*LiveChatMessageBuffer
 	streamChatBuffersMut sync.RWMutex
 }
 
-func (s *LiveChatBufferGroup) PushMessage(streamID uint64, msgID string, msg *ChatMsg) {
+func (s *LiveChatBufferGroup) PushMessage(streamID uint64, msgID string, user *ChatUserInfo, msg *ChatMsg) {
 
 	// Lock on the buffers
 	s.streamChatBuffersMut.Lock()
@@ -100,7 +174,7 @@ func (s *LiveChatBufferGroup) PushMessage(streamID uint64, msgID string, msg *Ch
 	}
 
 	// Push the message
-	buf.Push(msgID, msg)
+	buf.Push(msgID, user, msg)
 
 }
 
@@ -126,6 +200,50 @@ func (s *LiveChatBufferGroup) RevokeMessage(streamID uint64, msgID string) {
 
 }
 
+func (s *LiveChatBufferGroup) RevokeUserMessages(streamID uint64, user *ChatUserInfo) []string {
+
+	// Lock on the buffers
+	s.streamChatBuffersMut.Lock()
+	defer s.streamChatBuffersMut.Unlock()
+
+	// If the buffers map is nil, bail out now
+	if s.streamChatBuffers == nil {
+		return nil
+	}
+
+	// Get the buffer for this stream identifier
+	buf, ok := s.streamChatBuffers[streamID]
+	if !ok {
+		return nil
+	}
+
+	// Revoke the user's messages
+	return buf.RevokeUser(user)
+
+}
+
+func (s *LiveChatBufferGroup) ClearMessages(streamID uint64) {
+
+	// Lock on the buffers
+	s.streamChatBuffersMut.Lock()
+	defer s.streamChatBuffersMut.Unlock()
+
+	// If the buffers map is nil, bail out now
+	if s.streamChatBuffers == nil {
+		return
+	}
+
+	// Get the buffer for this stream identifier
+	buf, ok := s.streamChatBuffers[streamID]
+	if !ok {
+		return
+	}
+
+	// Clear the buffer
+	buf.Clear()
+
+}
+
 func (s *LiveChatBufferGroup) CopyMessages(streamID uint64) []*wrappedMsg {
 
 	// Lock on the buffers
@@ -147,3 +265,145 @@ func (s *LiveChatBufferGroup) CopyMessages(streamID uint64) []*wrappedMsg {
 	return buf.GetCopy()
 
 }
+
+func (s *LiveChatBufferGroup) GetMessage(streamID uint64, msgID string) *wrappedMsg {
+
+	// Lock on the buffers
+	s.streamChatBuffersMut.RLock()
+	defer s.streamChatBuffersMut.RUnlock()
+
+	// If the buffers map is nil, return nil
+	if s.streamChatBuffers == nil {
+		return nil
+	}
+
+	// Get the buffer for this stream identifier
+	buf, ok := s.streamChatBuffers[streamID]
+	if !ok {
+		return nil
+	}
+
+	// Find the message in the buffer
+	for _, msg := range buf.items {
+		if msg.ID == msgID {
+			return msg
+		}
+	}
+	return nil
+
+}
+
+// EditMessage replaces the contents of a buffered message, keeping its place and reactions. The wrapped message is
+// swapped for a new one rather than changed, since copies of the buffer are read without the lock. False is
+// returned if the message isn't in the buffer.
+func (s *LiveChatBufferGroup) EditMessage(streamID uint64, msgID string, msg *ChatMsg) bool {
+
+	// Lock on the buffers
+	s.streamChatBuffersMut.Lock()
+	defer s.streamChatBuffersMut.Unlock()
+
+	// Find the message in the buffer
+	buf, ok := s.streamChatBuffers[streamID]
+	if !ok {
+		return false
+	}
+	for i, item := range buf.items {
+		if item.ID == msgID {
+			buf.items[i] = &wrappedMsg{
+				ID:        item.ID,
+				IpAddress: item.IpAddress,
+				AccountID: item.AccountID,
+				SentDate:  item.SentDate,
+				Message:   msg,
+				reactions: item.reactions,
+			}
+			return true
+		}
+	}
+	return false
+
+}
+
+// React adds or removes a reaction on a buffered message. The first return value is false if the message isn't in
+// the buffer, and the second is false if the reaction was already in that state.
+func (s *LiveChatBufferGroup) React(
+	streamID uint64,
+	msgID string,
+	emoji string,
+	identity string,
+	remove bool,
+) (bool, bool) {
+
+	// Lock on the buffers
+	s.streamChatBuffersMut.Lock()
+	defer s.streamChatBuffersMut.Unlock()
+
+	// Find the message in the buffer
+	buf, ok := s.streamChatBuffers[streamID]
+	if !ok {
+		return false, false
+	}
+	var msg *wrappedMsg
+	for _, item := range buf.items {
+		if item.ID == msgID {
+			msg = item
+			break
+		}
+	}
+	if msg == nil {
+		return false, false
+	}
+
+	// Remove the reaction, if there is one
+	identities := msg.reactions[emoji]
+	if remove {
+		if !identities[identity] {
+			return true, false
+		}
+		delete(identities, identity)
+		if len(identities) == 0 {
+			delete(msg.reactions, emoji)
+		}
+		return true, true
+	}
+
+	// Otherwise add the reaction, if there isn't one already
+	if identities[identity] {
+		return true, false
+	}
+	if msg.reactions == nil {
+		msg.reactions = map[string]map[string]bool{}
+	}
+	if identities == nil {
+		identities = map[string]bool{}
+		msg.reactions[emoji] = identities
+	}
+	identities[identity] = true
+	return true, true
+
+}
+
+// ReactionCounts gets the number of reactions with each emoji on a buffered message
+func (s *LiveChatBufferGroup) ReactionCounts(streamID uint64, msgID string) map[string]int {
+
+	// Lock on the buffers
+	s.streamChatBuffersMut.RLock()
+	defer s.streamChatBuffersMut.RUnlock()
+
+	// Count the reactions on the message
+	counts := map[string]int{}
+	buf, ok := s.streamChatBuffers[streamID]
+	if !ok {
+		return counts
+	}
+	for _, item := range buf.items {
+		if item.ID == msgID {
+			for emoji, identities := range item.reactions {
+				counts[emoji] = len(identities)
+			}
+			break
+		}
+	}
+	return counts
+
+}
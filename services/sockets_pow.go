@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/utils"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+const (
+	// powBaseDifficulty is the difficulty of a challenge, in leading zero bits, for a client joining for the
+	// first time in a while
+	powBaseDifficulty = 16
+
+	// powMaxDifficulty is the most difficult a challenge gets, no matter how often a client joins
+	powMaxDifficulty = 24
+
+	// powJoinWindow is how far back joins from the same IP address are counted to scale the difficulty
+	powJoinWindow = time.Minute
+)
+
+// powChallenge is a proof-of-work challenge issued to a connection for a chat room
+type powChallenge struct {
+	Challenge  string
+	Difficulty int
+	Solved     bool
+}
+
+// issueProofOfWork sends a proof-of-work challenge to a connection joining a chat room that requires one. The
+// difficulty grows with the number of recent joins from the same IP address, so opening many connections gets
+// expensive. A connection that already solved a challenge for the room isn't asked again.
+func (s *SocketsService) issueProofOfWork(conn socketio.Conn, chatRoom *models.ChatRoom) {
+
+	// Get the session on the connection
+	session := getConnSession(conn)
+	if session == nil {
+		return
+	}
+
+	// Count the join, and get the difficulty for the IP address
+	ipAddress := s.IpResolver.GetIpAddress(conn.RemoteHeader(), conn.RemoteAddr())
+	joins := s.powJoins.Hit(ipAddress, time.Now())
+	difficulty := utils.ProofOfWorkDifficulty(joins, powBaseDifficulty, powMaxDifficulty)
+
+	// Create the challenge, unless the room was already solved
+	session.powChallengesMut.Lock()
+	if session.powChallenges == nil {
+		session.powChallenges = map[uint64]*powChallenge{}
+	}
+	challenge, ok := session.powChallenges[chatRoom.ID]
+	if !ok || !challenge.Solved {
+		challenge = &powChallenge{
+			Challenge:  utils.RandHexStr(32),
+			Difficulty: difficulty,
+		}
+		session.powChallenges[chatRoom.ID] = challenge
+	}
+	session.powChallengesMut.Unlock()
+
+	// Send the challenge to the viewer
+	conn.Emit("chat.pow-challenge", map[string]interface{}{
+		"chat_room_identifier": chatRoom.Identifier,
+		"challenge":            challenge.Challenge,
+		"difficulty":           challenge.Difficulty,
+		"solved":               challenge.Solved,
+	})
+
+}
+
+// checkProofOfWork makes sure a connection has solved the proof-of-work challenge of a chat room before it can
+// send messages there. The solution only needs to be sent once, with the first message.
+func (s *SocketsService) checkProofOfWork(conn socketio.Conn, chatRoom *models.ChatRoom, solution string) error {
+
+	// If the chat room doesn't require proof-of-work
+	if !chatRoom.ProofOfWork {
+		return nil
+	}
+
+	// Get the challenge issued when the connection joined the room
+	session := getConnSession(conn)
+	if session == nil {
+		return errors.New("proof of work required")
+	}
+	session.powChallengesMut.Lock()
+	defer session.powChallengesMut.Unlock()
+	challenge, ok := session.powChallenges[chatRoom.ID]
+	if !ok {
+		return errors.New("proof of work required")
+	}
+
+	// Check the solution, if the challenge hasn't been solved already
+	if challenge.Solved {
+		return nil
+	}
+	if !utils.VerifyProofOfWork(challenge.Challenge, solution, challenge.Difficulty) {
+		return errors.New("invalid proof of work")
+	}
+	challenge.Solved = true
+	return nil
+
+}
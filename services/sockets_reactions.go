@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// reactionBroadcastInterval is the least time between two broadcasts of reaction counts in a chat room. Reactions
+// made in between are sent together, so busy rooms don't flood viewers with an event for every reaction.
+const reactionBroadcastInterval = time.Second
+
+// reactionThrottle collects the messages in each chat room whose reactions changed since the last broadcast
+type reactionThrottle struct {
+	pending    map[uint64]map[string]bool
+	pendingMut sync.Mutex
+}
+
+// queueReactionBroadcast marks a message as having new reactions, scheduling a broadcast of the chat room's changed
+// reaction counts if one isn't already coming up
+func (s *SocketsService) queueReactionBroadcast(chatRoom *models.ChatRoom, msgID string) {
+
+	// Lock on the pending messages
+	s.reactions.pendingMut.Lock()
+	defer s.reactions.pendingMut.Unlock()
+
+	// If the pending map is nil, create it
+	if s.reactions.pending == nil {
+		s.reactions.pending = map[uint64]map[string]bool{}
+	}
+
+	// Schedule a broadcast if this is the first change since the last one
+	msgIDs, ok := s.reactions.pending[chatRoom.ID]
+	if !ok {
+		msgIDs = map[string]bool{}
+		s.reactions.pending[chatRoom.ID] = msgIDs
+		time.AfterFunc(reactionBroadcastInterval, func() {
+			s.broadcastReactions(chatRoom)
+		})
+	}
+	msgIDs[msgID] = true
+
+}
+
+// broadcastReactions sends the reaction counts of every message whose reactions changed since the last broadcast to
+// a chat room
+func (s *SocketsService) broadcastReactions(chatRoom *models.ChatRoom) {
+
+	// Take the changed messages
+	s.reactions.pendingMut.Lock()
+	msgIDs := s.reactions.pending[chatRoom.ID]
+	delete(s.reactions.pending, chatRoom.ID)
+	s.reactions.pendingMut.Unlock()
+
+	// Broadcast their reaction counts
+	reactionsSer := []map[string]interface{}{}
+	for msgID := range msgIDs {
+		reactionsSer = append(reactionsSer, map[string]interface{}{
+			"id":        msgID,
+			"reactions": s.chatBuffers.ReactionCounts(chatRoom.ID, msgID),
+		})
+	}
+	if len(reactionsSer) == 0 {
+		return
+	}
+	s.Broadcast(socketRoomName(chatRoom), "chat.reactions", reactionsSer)
+
+}
+
+//====================================================================================================
+// chatroom.react event handler
+// Called when a viewer reacts to a message, or takes their reaction back
+//====================================================================================================
+
+type ChatRoomReactMsg struct {
+	ChatRoomIdentifier string   `json:"chat_room_identifier"`
+	MessageID          string   `json:"message_id"`
+	Emoji              string   `json:"emoji"`
+	User               ChatUser `json:"user"`
+	Remove             bool     `json:"remove"`
+}
+
+func (s *SocketsService) OnChatRoomReact(conn socketio.Conn, data ChatRoomReactMsg) error {
+
+	// Get the stream with the identifier
+	chatRoom, err := s.ChatService.GetChatRoomByIdentifier(data.ChatRoomIdentifier)
+	if err != nil {
+		return err
+	}
+	if chatRoom == nil {
+		return errors.New("chat room not found")
+	}
+
+	// Make sure the organization allows reactions with the emoji
+	organization, err := s.OrganizationsService.GetOrganizationByID(chatRoom.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if organization == nil || !IsAllowedReaction(organization, data.Emoji) {
+		return errors.New("reaction not allowed")
+	}
+
+	// Wrap the chat user info
+	chatUserInfo := ChatUserInfo{
+		Username:  data.User.Username,
+		IpAddress: s.IpResolver.GetIpAddress(conn.RemoteHeader(), conn.RemoteAddr()),
+		Account:   getConnAccount(conn),
+	}
+
+	// Muted viewers can't react, but aren't told so
+	muted, err := s.ChatService.IsUserMuted(chatRoom, &chatUserInfo)
+	if err != nil {
+		return err
+	}
+	if muted {
+		return nil
+	}
+
+	// Add or remove the reaction, and let the room know if it changed
+	found, changed := s.chatBuffers.React(
+		chatRoom.ID,
+		data.MessageID,
+		data.Emoji,
+		reactionIdentity(&chatUserInfo),
+		data.Remove,
+	)
+	if !found {
+		return errors.New("message not found")
+	}
+	if changed {
+		s.queueReactionBroadcast(chatRoom, data.MessageID)
+	}
+	return nil
+
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// studioNamespace is the socket namespace used by authenticated moderators. Unlike the viewer namespace, events
+// sent here include private details like IP addresses.
+const studioNamespace = "/studio"
+
+func studioRoomName(organizationID uint64) string {
+	return fmt.Sprintf("studio_org_%d", organizationID)
+}
+
+// setupStudio registers the handlers for the studio namespace
+func (s *SocketsService) setupStudio() {
+
+	// Only authenticated accounts can connect to the studio
+	s.Server.OnConnect(studioNamespace, func(conn socketio.Conn) error {
+		if err := s.authenticateConn(conn); err != nil {
+			return err
+		}
+		if getConnAccount(conn) == nil {
+			return errors.New("authentication required")
+		}
+		fmt.Println("studio connected: ", conn.RemoteAddr().String())
+		return nil
+	})
+
+	// When a studio socket disconnects
+	s.Server.OnDisconnect(studioNamespace, func(conn socketio.Conn, reason string) {
+		fmt.Println("studio disconnected: ", conn.RemoteAddr().String())
+		conn.LeaveAll()
+	})
+
+	// Register the studio event handlers
+	s.Server.OnEvent(studioNamespace, "studio.join", s.OnStudioJoin)
+	s.Server.OnEvent(studioNamespace, "studio.leave", s.OnStudioLeave)
+
+	// Moderators can take action from the studio too
+	s.Server.OnEvent(studioNamespace, "mod.mute", s.OnModMute)
+	s.Server.OnEvent(studioNamespace, "mod.timeout", s.OnModTimeout)
+	s.Server.OnEvent(studioNamespace, "mod.unmute", s.OnModUnmute)
+	s.Server.OnEvent(studioNamespace, "mod.revoke", s.OnModRevoke)
+	s.Server.OnEvent(studioNamespace, "mod.purge-user", s.OnModPurgeUser)
+	s.Server.OnEvent(studioNamespace, "mod.clear", s.OnModClear)
+	s.Server.OnEvent(studioNamespace, "mod.approve", s.OnModApprove)
+	s.Server.OnEvent(studioNamespace, "mod.reject", s.OnModReject)
+
+}
+
+// BroadcastStudio broadcasts a message to every moderator connected to an organization's studio feed
+func (s *SocketsService) BroadcastStudio(organizationID uint64, event string, args ...interface{}) bool {
+	return s.Server.BroadcastToRoom(studioNamespace, studioRoomName(organizationID), event, args...)
+}
+
+//====================================================================================================
+// studio.join event handler
+// Called when a moderator starts watching the live feed of an organization
+//====================================================================================================
+
+type StudioJoinMsg struct {
+	OrganizationID uint64 `json:"organization_id"`
+}
+
+func (s *SocketsService) OnStudioJoin(conn socketio.Conn, data StudioJoinMsg) error {
+
+	// Make sure the account can moderate the organization
+	canModerate, err := s.OrganizationsService.CanAccountModerate(getConnAccount(conn), data.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if !canModerate {
+		return errors.New("not allowed to moderate this organization")
+	}
+
+	// Join the room for the organization, and catch up on the messages waiting for review
+	conn.Join(studioRoomName(data.OrganizationID))
+	s.emitHeldMessages(conn, data.OrganizationID)
+	return nil
+
+}
+
+//====================================================================================================
+// studio.leave event handler
+// Called when a moderator stops watching the live feed of an organization
+//====================================================================================================
+
+func (s *SocketsService) OnStudioLeave(conn socketio.Conn, data StudioJoinMsg) error {
+	conn.Leave(studioRoomName(data.OrganizationID))
+	return nil
+}
+
+//====================================================================================================
+// Studio message feed
+//====================================================================================================
+
+func serializeVerdict(verdict *MessageVerdict) map[string]interface{} {
+	var bannedWord interface{}
+	if verdict.BannedWord != nil {
+		bannedWord = map[string]interface{}{
+			"id":   verdict.BannedWord.ID,
+			"word": verdict.BannedWord.Word,
+		}
+	}
+	var spamRule interface{}
+	if verdict.SpamRule != nil {
+		spamRule = SerializeSpamRule(verdict.SpamRule)
+	}
+	var trustLevel interface{}
+	if verdict.ChatUser != nil {
+		trustLevel = verdict.ChatUser.TrustLevel(time.Now())
+	}
+	var impersonation interface{}
+	if verdict.Impersonation != nil {
+		impersonation = map[string]interface{}{
+			"name":  verdict.Impersonation.Name,
+			"exact": verdict.Impersonation.Exact,
+		}
+	}
+	return map[string]interface{}{
+		"allowed":       verdict.Allowed,
+		"reason":        verdict.Reason,
+		"banned_word":   bannedWord,
+		"spam_rule":     spamRule,
+		"link_domain":   verdict.LinkDomain,
+		"hold_reason":   verdict.HoldReason,
+		"impersonation": impersonation,
+		"trust_level":   trustLevel,
+	}
+}
+
+// BroadcastStudioMessage sends a chat message, along with the sender's identity and the verdict on the message,
+// to the moderators of the chat room's organization. Blocked messages are included.
+func (s *SocketsService) BroadcastStudioMessage(
+	chatRoom *models.ChatRoom,
+	msgID string,
+	user *ChatUserInfo,
+	msg *ChatMsg,
+	verdict *MessageVerdict,
+) {
+	s.BroadcastStudio(
+		chatRoom.OrganizationID,
+		"studio.messages",
+		[]map[string]interface{}{
+			{
+				"id":                   msgID,
+				"chat_room_identifier": chatRoom.Identifier,
+				"username":             user.Username,
+				"ip_address":           user.IpAddress,
+				"photo_url":            msg.User.PhotoUrl,
+				"message":              msg.Message,
+				"verdict":              serializeVerdict(verdict),
+				"date":                 time.Now().UTC().Unix(),
+			},
+		},
+	)
+}
+
+//====================================================================================================
+// Mute sweeper
+// Periodically marks timed mutes as expired and lets moderators know
+//====================================================================================================
+
+// RunMuteSweeper marks expired mutes on an interval, notifying the moderators of each organization about the mutes
+// that expired. It blocks forever, so it should be run in its own goroutine.
+func (s *SocketsService) RunMuteSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.sweepMutes()
+	}
+}
+
+// sweepMutes marks expired mutes a single time
+func (s *SocketsService) sweepMutes() {
+
+	// Expire all of the mutes that are past their until date
+	mutes, err := s.ChatService.ExpireMutes()
+	if err != nil {
+		fmt.Println("Error expiring mutes: ", err.Error())
+		return
+	}
+
+	// Notify the moderators of each mute
+	for _, mute := range mutes {
+		s.BroadcastStudio(
+			mute.OrganizationID,
+			"studio.mute-expired",
+			SerializeMutedUser(mute),
+		)
+	}
+
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ErrBlobNotFound is returned when a blob doesn't exist in storage
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStorage stores files under slash-separated keys, like "badges/1f2e.png". Implementations can keep the files
+// anywhere, so long as a blob put under a key can be read back with the same key.
+type BlobStorage interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// CleanBlobKey checks that a key stays inside the storage, and returns it in its clean form. Keys can't be empty,
+// absolute, or climb out with "..".
+func CleanBlobKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if len(cleaned) == 0 || cleaned != key {
+		return "", errors.New("invalid blob key")
+	}
+	return cleaned, nil
+}
+
+// LocalBlobStorage keeps blobs as files in a directory on the local disk
+type LocalBlobStorage struct {
+	Dir string
+}
+
+// filePath gets the path of the file for a key
+func (s *LocalBlobStorage) filePath(key string) (string, error) {
+	cleaned, err := CleanBlobKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(cleaned)), nil
+}
+
+// Put writes a blob to its file, creating any missing directories. The data goes to a temporary file first, so
+// readers never see a partly written blob.
+func (s *LocalBlobStorage) Put(key string, data []byte) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	tmpPath := filePath + ".tmp-" + RandHexStrInt64()
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Get reads a blob from its file
+func (s *LocalBlobStorage) Get(key string) ([]byte, error) {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete removes the file of a blob. Deleting a blob that doesn't exist is not an error.
+func (s *LocalBlobStorage) Delete(key string) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCleanBlobKey(t *testing.T) {
+	type blobKeyTest struct {
+		key   string
+		valid bool
+	}
+	testCases := []blobKeyTest{
+		{"badges/1f2e.png", true},
+		{"logo.png", true},
+		{"", false},
+		{"/badges/1f2e.png", false},
+		{"../secrets", false},
+		{"badges/../../secrets", false},
+		{"badges/./1f2e.png", false},
+		{"badges/", false},
+	}
+	for _, testCase := range testCases {
+		_, err := CleanBlobKey(testCase.key)
+		if valid := err == nil; valid != testCase.valid {
+			t.Errorf("key %q: expected valid %t, got %t", testCase.key, testCase.valid, valid)
+		}
+	}
+}
+
+func TestLocalBlobStorage(t *testing.T) {
+	storage := &LocalBlobStorage{Dir: t.TempDir()}
+
+	// Missing blobs aren't found
+	if _, err := storage.Get("badges/missing.png"); err != ErrBlobNotFound {
+		t.Errorf("expected missing blob to be not found, got %v", err)
+	}
+
+	// Blobs can be read back after they are put
+	data := []byte("badge")
+	if err := storage.Put("badges/badge.png", data); err != nil {
+		t.Fatalf("putting blob: %v", err)
+	}
+	read, err := storage.Get("badges/badge.png")
+	if err != nil || !bytes.Equal(read, data) {
+		t.Errorf("expected %q, got %q (%v)", data, read, err)
+	}
+
+	// Deleted blobs are gone, and deleting them again is fine
+	if err := storage.Delete("badges/badge.png"); err != nil {
+		t.Errorf("deleting blob: %v", err)
+	}
+	if _, err := storage.Get("badges/badge.png"); err != ErrBlobNotFound {
+		t.Errorf("expected deleted blob to be not found, got %v", err)
+	}
+	if err := storage.Delete("badges/badge.png"); err != nil {
+		t.Errorf("deleting missing blob: %v", err)
+	}
+
+	// Keys can't escape the directory
+	if err := storage.Put("../escaped.png", data); err == nil {
+		t.Errorf("expected escaping key to be rejected")
+	}
+
+}
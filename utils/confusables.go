@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// confusableRunes maps characters to the plain letter they are easily mistaken for. This covers the lookalikes
+// most used to impersonate a name: Cyrillic and Greek letters, accented Latin letters, and digits or symbols that
+// pass for letters. Capital I is read as a lowercase L in most fonts, so every kind of I collapses to L.
+var confusableRunes = map[rune]rune{}
+
+func init() {
+	groups := map[rune]string{
+		'a': "аαàáâãäåāăąǎ@4",
+		'b': "ьвβ8",
+		'c': "сςçćĉċč¢",
+		'd': "ԁďđ",
+		'e': "еεèéêëēĕėęě3",
+		'g': "ɡĝğġģ9",
+		'h': "һĥħ",
+		'j': "јĵ",
+		'k': "кκķ",
+		'l': "iіιìíîïĩīĭįıӏ1|ĺļľŀł",
+		'm': "м",
+		'n': "ηпñńņňŉ",
+		'o': "оοσòóôõöøōŏő0",
+		'p': "рρ",
+		'r': "гŕŗř",
+		's': "ѕśŝşš$5",
+		't': "тτţťŧ7",
+		'u': "υùúûüũūŭůűų",
+		'v': "ν",
+		'w': "ѡŵ",
+		'x': "хχ",
+		'y': "уýÿŷ",
+		'z': "źżž2",
+	}
+	for plain, lookalikes := range groups {
+		for _, r := range lookalikes {
+			confusableRunes[r] = plain
+		}
+	}
+}
+
+// confusableSequences are runs of letters that look like a single letter once squeezed together
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// ConfusableSkeleton reduces a name to a form where names that look alike come out the same. Case, separators,
+// invisible characters and accents are dropped, and lookalike characters are replaced with the letter they pass
+// for. Skeletons are only meant to be compared with each other.
+func ConfusableSkeleton(name string) string {
+	var builder strings.Builder
+	for _, r := range name {
+
+		// Fullwidth forms are the same as their ASCII counterparts
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		r = unicode.ToLower(r)
+
+		// Lookalikes become the letter they pass for
+		if plain, ok := confusableRunes[r]; ok {
+			r = plain
+		}
+
+		// Only letters and numbers are kept, which drops separators, punctuation and invisible characters
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			continue
+		}
+		builder.WriteRune(r)
+
+	}
+	return confusableSequences.Replace(builder.String())
+}
@@ -0,0 +1,38 @@
+package utils
+
+import "testing"
+
+func TestConfusableSkeleton(t *testing.T) {
+	type skeletonTest struct {
+		a        string
+		b        string
+		expected bool
+	}
+	testCases := []skeletonTest{
+		{"Streamer", "streamer", true},
+		{"Streamer", "Stream_er", true},
+		{"Streamer", "ѕtrеаmеr", true},
+		{"Streamer", "Ｓｔｒｅａｍｅｒ", true},
+		{"Streamer", "5tr3am3r", true},
+		{"Streamer", "Stréamér", true},
+		{"Streamer", "Strea​mer", true},
+		{"Modbot", "M0dbot", true},
+		{"Lily", "IiIy", true},
+		{"Marnie", "Mamie", true},
+		{"Streamer", "Streamers", false},
+		{"Streamer", "Dreamer", false},
+	}
+	for _, testCase := range testCases {
+		matches := ConfusableSkeleton(testCase.a) == ConfusableSkeleton(testCase.b)
+		if matches != testCase.expected {
+			t.Errorf(
+				"%q and %q: expected match %t (skeletons %q and %q)",
+				testCase.a,
+				testCase.b,
+				testCase.expected,
+				ConfusableSkeleton(testCase.a),
+				ConfusableSkeleton(testCase.b),
+			)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxEmojiBytes is the longest emoji accepted, which leaves room for sequences joined with zero width joiners
+const maxEmojiBytes = 32
+
+// IsEmoji checks if a string looks like a single emoji: a short run of symbols, optionally joined or modified with
+// variation selectors, zero width joiners, and skin tone modifiers. Letters, numbers, spaces and punctuation are
+// not allowed.
+func IsEmoji(str string) bool {
+	if len(str) == 0 || len(str) > maxEmojiBytes || !utf8.ValidString(str) {
+		return false
+	}
+	hasSymbol := false
+	for _, r := range str {
+		switch {
+		case unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r):
+			hasSymbol = true
+		case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r):
+			// Variation selectors, combining keycaps, and zero width joiners
+		default:
+			return false
+		}
+	}
+	return hasSymbol
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestIsEmoji(t *testing.T) {
+	type emojiTest struct {
+		str      string
+		expected bool
+	}
+	testCases := []emojiTest{
+		{"👍", true},
+		{"❤️", true},
+		{"👍🏽", true},
+		{"👩‍💻", true},
+		{"🇨🇦", true},
+		{"", false},
+		{"a", false},
+		{"👍 👍", false},
+		{"1", false},
+		{"!", false},
+		{"‍", false},
+		{"🔥🔥🔥🔥🔥🔥🔥🔥🔥", false},
+	}
+	for _, testCase := range testCases {
+		if isEmoji := IsEmoji(testCase.str); isEmoji != testCase.expected {
+			t.Errorf("%q: expected %t, got %t", testCase.str, testCase.expected, isEmoji)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// FragmentText is plain text
+	FragmentText = "text"
+
+	// FragmentEmote is an emote code, like ":hype:". Value is the code without the colons.
+	FragmentEmote = "emote"
+
+	// FragmentMention is a mention of another chatter, like "@someone". Value is the username without the "@".
+	FragmentMention = "mention"
+
+	// FragmentLink is a web link. Value is the URL to open, with a scheme added if the link was written without one.
+	FragmentLink = "link"
+)
+
+var (
+	// fragmentLink matches a whole link as written in a message: a web URL, or a domain name with an optional port
+	// and path. Other schemes are left as text, so they can never be rendered as clickable.
+	fragmentLink = regexp.MustCompile(`(?i)\bhttps?://[^\s]+|` + linkDomainPattern + `(?::\d{1,5})?(?:[/?#][^\s]*)?`)
+
+	// fragmentEmote matches an emote code at the start of the text
+	fragmentEmote = regexp.MustCompile(`^:([A-Za-z0-9_]{2,32}):`)
+
+	// fragmentMention matches a mention at the start of the text
+	fragmentMention = regexp.MustCompile(`^@([\p{L}\p{N}_-]+)`)
+
+	// fragmentScheme matches the scheme at the start of a link
+	fragmentScheme = regexp.MustCompile(`(?i)^https?://`)
+)
+
+// fragmentLinkTrailing is the punctuation trimmed from the end of a link, since it usually ends the sentence rather
+// than the link
+const fragmentLinkTrailing = `.,!?;:'")]}>`
+
+// MessageFragment is a piece of a chat message. Text is the fragment as it was written, and Value depends on Type.
+type MessageFragment struct {
+	Type  string
+	Text  string
+	Value string
+}
+
+// TokenizeMessage splits a message into fragments of text, emotes, mentions and links. Emote codes are only
+// recognized when isEmote accepts them, and are otherwise left as text. Joining the text of the fragments gives
+// back the message.
+func TokenizeMessage(message string, isEmote func(code string) bool) []MessageFragment {
+	fragments := []MessageFragment{}
+	start := 0
+	for _, match := range fragmentLink.FindAllStringIndex(message, -1) {
+
+		// Leave the punctuation at the end of the link out of it
+		end := match[1]
+		for end > match[0] && strings.ContainsRune(fragmentLinkTrailing, rune(message[end-1])) {
+			end--
+		}
+		if end == match[0] {
+			continue
+		}
+
+		// Split up the text before the link, then add the link
+		fragments = tokenizeText(fragments, message, start, match[0], isEmote)
+		link := message[match[0]:end]
+		url := link
+		if !fragmentScheme.MatchString(url) {
+			url = "https://" + url
+		}
+		fragments = append(fragments, MessageFragment{Type: FragmentLink, Text: link, Value: url})
+		start = end
+
+	}
+	return tokenizeText(fragments, message, start, len(message), isEmote)
+}
+
+// tokenizeText splits the part of a message between from and to, which has no links in it, into text, emote and
+// mention fragments
+func tokenizeText(
+	fragments []MessageFragment,
+	message string,
+	from int,
+	to int,
+	isEmote func(code string) bool,
+) []MessageFragment {
+	start := from
+	for i := from; i < to; {
+
+		// Check for an emote or a mention starting here
+		var fragment *MessageFragment
+		switch message[i] {
+		case ':':
+			if match := fragmentEmote.FindStringSubmatch(message[i:to]); match != nil && isEmote(match[1]) {
+				fragment = &MessageFragment{Type: FragmentEmote, Text: match[0], Value: match[1]}
+			}
+		case '@':
+			prev, _ := utf8.DecodeLastRuneInString(message[:i])
+			if i > 0 && (unicode.IsLetter(prev) || unicode.IsNumber(prev) || prev == '_') {
+				break
+			}
+			if match := fragmentMention.FindStringSubmatch(message[i:to]); match != nil {
+				fragment = &MessageFragment{Type: FragmentMention, Text: match[0], Value: match[1]}
+			}
+		}
+
+		// If there isn't one, move on to the next character
+		if fragment == nil {
+			_, size := utf8.DecodeRuneInString(message[i:])
+			i += size
+			continue
+		}
+
+		// Add the text before the fragment, then the fragment itself
+		if i > start {
+			fragments = append(fragments, MessageFragment{Type: FragmentText, Text: message[start:i]})
+		}
+		fragments = append(fragments, *fragment)
+		i += len(fragment.Text)
+		start = i
+
+	}
+	if to > start {
+		fragments = append(fragments, MessageFragment{Type: FragmentText, Text: message[start:to]})
+	}
+	return fragments
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeMessage(t *testing.T) {
+	isEmote := func(code string) bool {
+		return code == "hype" || code == "gg"
+	}
+	type tokenizeTest struct {
+		message  string
+		expected []MessageFragment
+	}
+	testCases := []tokenizeTest{
+		// Plain text
+		{"", []MessageFragment{}},
+		{"hello world", []MessageFragment{{FragmentText, "hello world", ""}}},
+		// Emotes, with unknown codes left as text
+		{":hype: let's go :gg:", []MessageFragment{
+			{FragmentEmote, ":hype:", "hype"},
+			{FragmentText, " let's go ", ""},
+			{FragmentEmote, ":gg:", "gg"},
+		}},
+		{"time :nope:hype: now", []MessageFragment{
+			{FragmentText, "time :nope", ""},
+			{FragmentEmote, ":hype:", "hype"},
+			{FragmentText, " now", ""},
+		}},
+		{"at 10:30:45", []MessageFragment{{FragmentText, "at 10:30:45", ""}}},
+		// Mentions, but not email addresses
+		{"hi @Some_One!", []MessageFragment{
+			{FragmentText, "hi ", ""},
+			{FragmentMention, "@Some_One", "Some_One"},
+			{FragmentText, "!", ""},
+		}},
+		{"mail bob@example.com", []MessageFragment{
+			{FragmentText, "mail bob@", ""},
+			{FragmentLink, "example.com", "https://example.com"},
+		}},
+		{"just an @ sign", []MessageFragment{{FragmentText, "just an @ sign", ""}}},
+		// Links, with trailing punctuation left out and unsafe schemes left as text
+		{"see https://example.com/a?b=1.", []MessageFragment{
+			{FragmentText, "see ", ""},
+			{FragmentLink, "https://example.com/a?b=1", "https://example.com/a?b=1"},
+			{FragmentText, ".", ""},
+		}},
+		{"(www.example.org/page)", []MessageFragment{
+			{FragmentText, "(", ""},
+			{FragmentLink, "www.example.org/page", "https://www.example.org/page"},
+			{FragmentText, ")", ""},
+		}},
+		{"javascript://alert", []MessageFragment{{FragmentText, "javascript://alert", ""}}},
+		{"pi is 3.14, e.g. close", []MessageFragment{{FragmentText, "pi is 3.14, e.g. close", ""}}},
+		// Everything together
+		{"@mod check example.com :hype:", []MessageFragment{
+			{FragmentMention, "@mod", "mod"},
+			{FragmentText, " check ", ""},
+			{FragmentLink, "example.com", "https://example.com"},
+			{FragmentText, " ", ""},
+			{FragmentEmote, ":hype:", "hype"},
+		}},
+	}
+	for _, testCase := range testCases {
+		fragments := TokenizeMessage(testCase.message, isEmote)
+		if !reflect.DeepEqual(fragments, testCase.expected) {
+			t.Errorf("tokenizing %q: expected %v, got %v", testCase.message, testCase.expected, fragments)
+		}
+
+		// The text of the fragments always adds back up to the message
+		var joined strings.Builder
+		for _, fragment := range fragments {
+			joined.WriteString(fragment.Text)
+		}
+		if joined.String() != testCase.message {
+			t.Errorf("tokenizing %q: fragments join to %q", testCase.message, joined.String())
+		}
+	}
+}
@@ -19,3 +19,20 @@ func TestSha256Hex(t *testing.T) {
 		}
 	}
 }
+
+func TestHmacSha256(t *testing.T) {
+	type hmacTest struct {
+		input  string
+		secret string
+		output string
+	}
+	testCases := []hmacTest{
+		{"The quick brown fox jumps over the lazy dog", "key", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+	for _, testCase := range testCases {
+		result := HmacSha256(testCase.input, testCase.secret)
+		if result != testCase.output {
+			t.Errorf("incorrect HMAC-SHA256 of '%s' => '%s' (expected %s)\n", testCase.input, result, testCase.output)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"net/http"
+
+	// Register the formats accepted by DecodeImage
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// imageTypes are the content types accepted by DecodeImage
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// DecodeImage decodes an uploaded PNG, JPEG or GIF image. The type is detected from the data rather than trusted
+// from the upload, and the dimensions are checked before decoding so huge images are turned away cheaply.
+func DecodeImage(data []byte, maxDimension int) (image.Image, error) {
+
+	// Check the type of the image
+	if !imageTypes[http.DetectContentType(data)] {
+		return nil, errors.New("image must be a PNG, JPEG or GIF")
+	}
+
+	// Check the dimensions of the image
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, errors.New("image is empty")
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, errors.New("image dimensions are too large")
+	}
+
+	// Decode the image
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+	return img, nil
+
+}
+
+// ResizeImage scales an image to the given dimensions. Each pixel of the result is the average of the source pixels
+// it covers, which keeps detail when shrinking; when enlarging, source pixels are repeated.
+func ResizeImage(src image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	for y := 0; y < height; y++ {
+
+		// Find the source rows covered by the row
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := bounds.Min.Y + (y+1)*srcHeight/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+
+			// Find the source columns covered by the pixel
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := bounds.Min.X + (x+1)*srcWidth/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			// Average the covered pixels, which are premultiplied by their alpha
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sr, sg, sb, sa := src.At(sx, sy).RGBA()
+					r += uint64(sr)
+					g += uint64(sg)
+					b += uint64(sb)
+					a += uint64(sa)
+					count++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / count >> 8),
+				G: uint8(g / count >> 8),
+				B: uint8(b / count >> 8),
+				A: uint8(a / count >> 8),
+			})
+
+		}
+	}
+	return dst
+}
+
+// FitImage scales an image to fit in a square of the given size, keeping its aspect ratio, and centers it on a
+// transparent background
+func FitImage(src image.Image, size int) *image.RGBA {
+
+	// Scale the longer side to the size of the square
+	bounds := src.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = bounds.Dy() * size / bounds.Dx()
+	} else if bounds.Dy() > bounds.Dx() {
+		width = bounds.Dx() * size / bounds.Dy()
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	scaled := ResizeImage(src, width, height)
+
+	// Center the scaled image in the square
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	offset := image.Pt((size-width)/2, (size-height)/2)
+	draw.Draw(dst, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Src)
+	return dst
+
+}
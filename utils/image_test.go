@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// solidImage creates an image filled with a single color
+func solidImage(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDecodeImage(t *testing.T) {
+
+	// Encode a small PNG
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solidImage(10, 20, color.RGBA{255, 0, 0, 255})); err != nil {
+		t.Fatalf("encoding png: %v", err)
+	}
+
+	type decodeTest struct {
+		data         []byte
+		maxDimension int
+		valid        bool
+	}
+	testCases := []decodeTest{
+		{buf.Bytes(), 100, true},
+		{buf.Bytes(), 20, true},
+		{buf.Bytes(), 19, false},
+		{[]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), 100, false},
+		{[]byte("not an image"), 100, false},
+		{buf.Bytes()[:30], 100, false},
+	}
+	for i, testCase := range testCases {
+		img, err := DecodeImage(testCase.data, testCase.maxDimension)
+		if valid := err == nil; valid != testCase.valid {
+			t.Errorf("case %d: expected valid %t, got %t (%v)", i, testCase.valid, valid, err)
+		}
+		if err == nil && img.Bounds().Dx() != 10 {
+			t.Errorf("case %d: expected width 10, got %d", i, img.Bounds().Dx())
+		}
+	}
+
+}
+
+func TestResizeImage(t *testing.T) {
+
+	// Shrinking a half-red, half-blue image averages the colors of each half
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				src.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				src.SetRGBA(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	dst := ResizeImage(src, 2, 1)
+	if c := dst.RGBAAt(0, 0); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("expected red on the left, got %v", c)
+	}
+	if c := dst.RGBAAt(1, 0); c != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("expected blue on the right, got %v", c)
+	}
+
+	// Shrinking the whole image to one pixel blends the two colors
+	if c := ResizeImage(src, 1, 1).RGBAAt(0, 0); c != (color.RGBA{127, 0, 127, 255}) {
+		t.Errorf("expected purple, got %v", c)
+	}
+
+	// Enlarging repeats the pixels
+	if bounds := ResizeImage(src, 8, 4).Bounds(); bounds.Dx() != 8 || bounds.Dy() != 4 {
+		t.Errorf("expected 8x4, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+}
+
+func TestFitImage(t *testing.T) {
+
+	// A wide image is centered vertically on a transparent square
+	dst := FitImage(solidImage(100, 50, color.RGBA{0, 255, 0, 255}), 10)
+	if bounds := dst.Bounds(); bounds.Dx() != 10 || bounds.Dy() != 10 {
+		t.Fatalf("expected 10x10, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if c := dst.RGBAAt(5, 0); c.A != 0 {
+		t.Errorf("expected transparent top, got %v", c)
+	}
+	if c := dst.RGBAAt(5, 5); c != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("expected green middle, got %v", c)
+	}
+	if c := dst.RGBAAt(5, 9); c.A != 0 {
+		t.Errorf("expected transparent bottom, got %v", c)
+	}
+
+}
@@ -11,21 +11,96 @@ import (
 // needed because the net.Addr includes a port number at the end
 var netAddrPattern = regexp.MustCompile(`^(.*):\d+$`)
 
-// GetIpAddress gets the IP address from a set of headers and a net address
-func GetIpAddress(
+// IpResolver resolves the IP address of a client. The forwarding header is only trusted when the request came
+// directly from one of the trusted proxies, so clients can't spoof their address by setting the header themselves.
+// Only the one header the proxies set is read, since they pass any other header through from the client untouched.
+type IpResolver struct {
+	trustedProxies []*net.IPNet
+	header         string
+}
+
+// NewIpResolver creates an IP resolver that trusts the provided proxy addresses or CIDR ranges to set the provided
+// forwarding header. If no header is provided, the address of the peer is always used.
+func NewIpResolver(trustedProxies []string, header string) (*IpResolver, error) {
+
+	// Parse all of the trusted proxies
+	resolver := IpResolver{
+		header: strings.TrimSpace(header),
+	}
+	for _, proxy := range trustedProxies {
+		ipNet, err := ParseIpRange(proxy)
+		if err != nil {
+			return nil, err
+		}
+		resolver.trustedProxies = append(resolver.trustedProxies, ipNet)
+	}
+
+	return &resolver, nil
+
+}
+
+// isTrustedProxy checks if an IP address belongs to a trusted proxy
+func (r *IpResolver) isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range r.trustedProxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIpAddress gets the IP address of a client from a set of headers and the net address of the connection
+func (r *IpResolver) GetIpAddress(
 	header http.Header,
 	addr net.Addr,
 ) string {
 
-	// If there are headers, try to pull the CF-Connecting-IP header, which is forwarded
-	// from Cloudflare in the event that Cloudflare is being used.
-	if header != nil {
-		ip := header.Get("CF-Connecting-IP")
-		if len(ip) > 0 {
-			return ip
+	// Get the address of the peer that connected to us
+	remoteIp := netAddrIp(addr)
+
+	// If there's no forwarding header, or the peer isn't a trusted proxy, its headers can't be trusted
+	if header == nil || len(r.header) == 0 || !r.isTrustedProxy(remoteIp) {
+		return remoteIp
+	}
+
+	// Get the chain of addresses in the header, from the client to the closest proxy
+	var chain []string
+	switch strings.ToLower(r.header) {
+	case "x-forwarded-for":
+		chain = parseForwardedFor(header.Values(r.header))
+	case "forwarded":
+		chain = parseForwarded(header.Values(r.header))
+	default:
+		chain = parseForwardedFor(header.Values(r.header))
+		if len(chain) > 1 {
+			chain = chain[:1]
 		}
 	}
 
+	// Walk the chain from right to left, skipping over our own proxies. The first address that isn't a trusted
+	// proxy is the client
+	for i := len(chain) - 1; i >= 0; i-- {
+		if i > 0 && r.isTrustedProxy(chain[i]) {
+			continue
+		}
+		if net.ParseIP(chain[i]) == nil {
+			break
+		}
+		return chain[i]
+	}
+
+	// Fall back to the address of the peer
+	return remoteIp
+
+}
+
+// netAddrIp gets the IP address out of a net address
+func netAddrIp(addr net.Addr) string {
+
 	// If the address is nil, return an empty string
 	if addr == nil {
 		return ""
@@ -38,9 +113,52 @@ func GetIpAddress(
 	}
 
 	// Clean up the IP address. These only have an effect in the case of IPv6 addresses
-	ip := submatch[1]
+	return cleanIp(submatch[1])
+
+}
+
+// cleanIp trims the brackets and IPv4-mapped prefix off of an IP address
+func cleanIp(ip string) string {
+	ip = strings.TrimSpace(ip)
 	ip = strings.Trim(ip, "[]")
 	ip = strings.TrimPrefix(ip, "::ffff:")
 	return ip
+}
 
+// parseForwardedFor parses the comma-separated addresses in X-Forwarded-For style headers
+func parseForwardedFor(values []string) []string {
+	chain := []string{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			chain = append(chain, cleanIp(part))
+		}
+	}
+	return chain
+}
+
+// parseForwarded parses the "for" addresses in an RFC 7239 Forwarded header
+func parseForwarded(values []string) []string {
+	chain := []string{}
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) != 2 || !strings.EqualFold(kv[0], "for") {
+					continue
+				}
+				node := strings.Trim(kv[1], "\"")
+				if strings.HasPrefix(node, "[") {
+					// IPv6 addresses are bracketed, and may have a port after the bracket
+					if end := strings.Index(node, "]"); end > 0 {
+						node = node[1:end]
+					}
+				} else if strings.Count(node, ":") == 1 {
+					// IPv4 addresses may have a port
+					node = node[:strings.Index(node, ":")]
+				}
+				chain = append(chain, cleanIp(node))
+			}
+		}
+	}
+	return chain
 }
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestIpResolver(t *testing.T) {
+
+	type resolverTest struct {
+		trusted  string
+		remote   string
+		headers  map[string]string
+		expected string
+	}
+	testCases := []resolverTest{
+		// Headers from untrusted peers are ignored
+		{"CF-Connecting-IP", "203.0.113.5:1234", map[string]string{"CF-Connecting-IP": "1.2.3.4"}, "203.0.113.5"},
+		{"X-Forwarded-For", "203.0.113.5:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.5"},
+		// The trusted header from trusted proxies is used
+		{"CF-Connecting-IP", "10.0.0.2:1234", map[string]string{"CF-Connecting-IP": "1.2.3.4"}, "1.2.3.4"},
+		{"X-Real-IP", "10.0.0.2:1234", map[string]string{"X-Real-IP": "1.2.3.4"}, "1.2.3.4"},
+		// Other headers are passed through from the client by the proxy, so they are ignored
+		{"X-Forwarded-For", "10.0.0.2:1234", map[string]string{"CF-Connecting-IP": "6.6.6.6", "X-Forwarded-For": "1.2.3.4"}, "1.2.3.4"},
+		{"X-Forwarded-For", "10.0.0.2:1234", map[string]string{"X-Real-IP": "6.6.6.6"}, "10.0.0.2"},
+		// Without a trusted header, the peer address is always used
+		{"", "10.0.0.2:1234", map[string]string{"CF-Connecting-IP": "6.6.6.6", "X-Forwarded-For": "6.6.6.6"}, "10.0.0.2"},
+		// X-Forwarded-For is walked right to left, so spoofed entries on the left are skipped
+		{"X-Forwarded-For", "10.0.0.2:1234", map[string]string{"X-Forwarded-For": "6.6.6.6, 1.2.3.4, 10.0.0.7"}, "1.2.3.4"},
+		{"X-Forwarded-For", "10.0.0.2:1234", map[string]string{"X-Forwarded-For": "10.0.0.9, 10.0.0.7"}, "10.0.0.9"},
+		// Forwarded headers are parsed
+		{"Forwarded", "10.0.0.2:1234", map[string]string{"Forwarded": `for=6.6.6.6, for="[2001:db8::1]:4711";proto=https`}, "2001:db8::1"},
+		{"Forwarded", "10.0.0.2:1234", map[string]string{"Forwarded": "for=1.2.3.4:5678"}, "1.2.3.4"},
+		// Without headers, the peer address is used
+		{"X-Forwarded-For", "[::ffff:10.0.0.2]:1234", map[string]string{}, "10.0.0.2"},
+		{"X-Forwarded-For", "[2001:db8::5]:1234", map[string]string{}, "2001:db8::5"},
+	}
+	for _, testCase := range testCases {
+
+		// Create a resolver that trusts a private proxy network to set the header
+		resolver, err := NewIpResolver([]string{"10.0.0.0/8"}, testCase.trusted)
+		if err != nil {
+			t.Fatalf("failed to create resolver: %s", err)
+		}
+
+		header := http.Header{}
+		for name, value := range testCase.headers {
+			header.Set(name, value)
+		}
+		addr, err := net.ResolveTCPAddr("tcp", testCase.remote)
+		if err != nil {
+			t.Fatalf("failed to parse address '%s': %s", testCase.remote, err)
+		}
+		result := resolver.GetIpAddress(header, addr)
+		if result != testCase.expected {
+			t.Errorf("incorrect IP for %s %v trusting '%s' => '%s' (expected %s)\n", testCase.remote, testCase.headers, testCase.trusted, result, testCase.expected)
+		}
+	}
+
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"net"
+	"strings"
+)
+
+// hashedIpPrefix marks a stored IP address as a keyed hash rather than a plain address
+const hashedIpPrefix = "h:"
+
+// IpHasher replaces IP addresses with keyed hashes before they are stored, so the raw addresses never reach the
+// database. The same address always hashes to the same value, so stored hashes can still be matched. A nil or
+// keyless hasher leaves addresses as they are.
+type IpHasher struct {
+	Key string
+}
+
+// Enabled checks if addresses are being hashed
+func (h *IpHasher) Enabled() bool {
+	return h != nil && len(h.Key) > 0
+}
+
+// IsHashedIp checks if a stored IP address is a keyed hash
+func IsHashedIp(stored string) bool {
+	return strings.HasPrefix(stored, hashedIpPrefix)
+}
+
+// HashIp gets the form of an IP address or range to store. Single addresses and IPv6 /64 networks are hashed.
+// Other ranges don't identify a single person and can't be matched once hashed, so they are stored as they are.
+func (h *IpHasher) HashIp(ipAddress string) string {
+
+	// If hashing is disabled, or the value is already hashed, leave it alone
+	if !h.Enabled() || len(ipAddress) == 0 || IsHashedIp(ipAddress) {
+		return ipAddress
+	}
+
+	// Parse the address or range, so equivalent forms hash to the same value
+	ipNet, err := ParseIpRange(ipAddress)
+	if err != nil {
+		return ipAddress
+	}
+	ones, bits := ipNet.Mask.Size()
+	if ones != bits && !(bits == 128 && ones == 64) {
+		return ipAddress
+	}
+
+	// Hash the canonical form of the address
+	return hashedIpPrefix + HmacSha256(IpRangeString(ipNet), h.Key)
+
+}
+
+// HashedForms gets every hashed value that a stored mute on an IP address could have. This is the hash of the
+// address itself and, for IPv6, the hash of its /64 network.
+func (h *IpHasher) HashedForms(ipAddress string) []string {
+
+	// If hashing is disabled, nothing can be stored hashed
+	if !h.Enabled() {
+		return nil
+	}
+
+	// Parse the address
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if ip == nil {
+		return nil
+	}
+
+	// Hash the address and its IPv6 network
+	forms := []string{h.HashIp(ip.String())}
+	if network := h.NetworkForm(ip.String()); len(network) > 0 {
+		forms = append(forms, network)
+	}
+	return forms
+
+}
+
+// NetworkForm gets the stored form of the IPv6 /64 network of an address, so messages can still be matched by their
+// network once addresses are hashed. An empty string is returned for IPv4 and invalid addresses.
+func (h *IpHasher) NetworkForm(ipAddress string) string {
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if ip == nil || ip.To4() != nil {
+		return ""
+	}
+	network := &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	return h.HashIp(IpRangeString(GroupIpv6(network)))
+}
+
+// StoredForms gets the values an IP address or range could have been stored as, whether it was stored before or
+// after hashing was enabled
+func (h *IpHasher) StoredForms(ipAddress string) []string {
+	forms := []string{ipAddress}
+	if hashed := h.HashIp(ipAddress); hashed != ipAddress {
+		forms = append(forms, hashed)
+	}
+	return forms
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestIpHasher(t *testing.T) {
+
+	// Hashing is disabled without a key
+	var disabled *IpHasher
+	if result := disabled.HashIp("203.0.113.7"); result != "203.0.113.7" {
+		t.Errorf("disabled hasher changed the address => '%s'", result)
+	}
+
+	// Equivalent forms of an address hash to the same value
+	hasher := &IpHasher{Key: "secret"}
+	hashed := hasher.HashIp("203.0.113.7")
+	if !IsHashedIp(hashed) {
+		t.Errorf("address was not hashed => '%s'", hashed)
+	}
+	if result := hasher.HashIp("203.0.113.7/32"); result != hashed {
+		t.Errorf("equivalent address hashed differently => '%s' (expected %s)", result, hashed)
+	}
+
+	// Ranges other than IPv6 /64 networks are left alone
+	if result := hasher.HashIp("203.0.113.0/24"); result != "203.0.113.0/24" {
+		t.Errorf("range was hashed => '%s'", result)
+	}
+
+	// An IPv6 address matches a hashed /64 mute on its network
+	network := hasher.HashIp("2001:db8:1:2::/64")
+	found := false
+	for _, form := range hasher.HashedForms("2001:db8:1:2::99") {
+		if form == network {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("IPv6 address did not match the hash of its /64 network")
+	}
+	if result := hasher.NetworkForm("2001:db8:1:2::99"); result != network {
+		t.Errorf("network form of IPv6 address => '%s' (expected %s)", result, network)
+	}
+	if result := hasher.NetworkForm("203.0.113.7"); result != "" {
+		t.Errorf("IPv4 address has a network form => '%s'", result)
+	}
+
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// ParseIpRange parses an IP address or a CIDR range. A single address is treated as a range containing only
+// that address. IPv4 addresses are always returned in their 4-byte form.
+func ParseIpRange(str string) (*net.IPNet, error) {
+
+	// Trim the string before parsing it
+	str = strings.TrimSpace(str)
+
+	// If it's a CIDR range
+	if strings.Contains(str, "/") {
+		_, ipNet, err := net.ParseCIDR(str)
+		if err != nil {
+			return nil, errors.New("invalid CIDR range")
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			ipNet.IP = ip4
+		}
+		return ipNet, nil
+	}
+
+	// Otherwise it should be a single address
+	ip := net.ParseIP(str)
+	if ip == nil {
+		return nil, errors.New("invalid IP address")
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+
+}
+
+// GroupIpv6 widens an IPv6 range to the /64 network that contains it, since IPv6 users commonly rotate through
+// addresses within a single /64. IPv4 ranges and IPv6 ranges that are already wider are returned unchanged.
+func GroupIpv6(ipNet *net.IPNet) *net.IPNet {
+	ones, bits := ipNet.Mask.Size()
+	if bits != 128 || ones <= 64 {
+		return ipNet
+	}
+	mask := net.CIDRMask(64, 128)
+	return &net.IPNet{
+		IP:   ipNet.IP.Mask(mask),
+		Mask: mask,
+	}
+}
+
+// IpRangeString formats an IP range as a string. Ranges containing a single address are formatted as just
+// the address.
+func IpRangeString(ipNet *net.IPNet) string {
+	ones, bits := ipNet.Mask.Size()
+	if ones == bits {
+		return ipNet.IP.String()
+	}
+	return ipNet.String()
+}
+
+// IpInRange checks if an IP address falls within an IP range, given as either a single address or a CIDR range
+func IpInRange(ipRange string, ipAddress string) bool {
+	ipNet, err := ParseIpRange(ipRange)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if ip == nil {
+		return false
+	}
+	return ipNet.Contains(ip)
+}
@@ -0,0 +1,81 @@
+package utils
+
+import "net"
+
+// ipTrieNode is a single node in a binary IP prefix trie
+type ipTrieNode struct {
+	children [2]*ipTrieNode
+	values   []uint64
+}
+
+// IpTrie is a binary prefix trie of IP ranges, used to quickly find every range that contains an address.
+// IPv4 and IPv6 ranges are kept in separate trees. It is not safe for concurrent writes.
+type IpTrie struct {
+	ipv4 ipTrieNode
+	ipv6 ipTrieNode
+}
+
+// rootAndBytes gets the tree and the address bytes to use for an IP
+func (t *IpTrie) rootAndBytes(ip net.IP) (*ipTrieNode, []byte) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &t.ipv4, ip4
+	}
+	if ip16 := ip.To16(); ip16 != nil {
+		return &t.ipv6, ip16
+	}
+	return nil, nil
+}
+
+// ipBit gets the bit at an index of an address, counting from the most significant bit
+func ipBit(ip []byte, index int) int {
+	return int(ip[index/8]>>(7-uint(index%8))) & 1
+}
+
+// Insert adds a range to the trie, associated with a value
+func (t *IpTrie) Insert(ipNet *net.IPNet, value uint64) {
+
+	// Get the tree for the address family
+	node, ip := t.rootAndBytes(ipNet.IP)
+	if node == nil {
+		return
+	}
+
+	// Walk down the tree for each bit of the prefix, creating nodes as needed
+	ones, _ := ipNet.Mask.Size()
+	if ones > len(ip)*8 {
+		ones = len(ip) * 8
+	}
+	for i := 0; i < ones; i++ {
+		bit := ipBit(ip, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &ipTrieNode{}
+		}
+		node = node.children[bit]
+	}
+
+	// Store the value at the end of the prefix
+	node.values = append(node.values, value)
+
+}
+
+// Match gets the values of every range in the trie that contains an IP address
+func (t *IpTrie) Match(ip net.IP) []uint64 {
+
+	// Get the tree for the address family
+	node, ipBytes := t.rootAndBytes(ip)
+	if node == nil {
+		return nil
+	}
+
+	// Walk down the tree, collecting the values of every prefix along the way
+	values := append([]uint64{}, node.values...)
+	for i := 0; i < len(ipBytes)*8; i++ {
+		node = node.children[ipBit(ipBytes, i)]
+		if node == nil {
+			break
+		}
+		values = append(values, node.values...)
+	}
+	return values
+
+}
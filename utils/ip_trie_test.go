@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIpTrie(t *testing.T) {
+
+	// Build a trie with a mix of ranges
+	var trie IpTrie
+	ranges := map[uint64]string{
+		1: "10.0.0.0/8",
+		2: "10.1.2.0/24",
+		3: "192.168.1.20",
+		4: "2001:db8:1:2::/64",
+		5: "2001:db8::/32",
+	}
+	for value, str := range ranges {
+		ipNet, err := ParseIpRange(str)
+		if err != nil {
+			t.Fatalf("failed to parse range '%s': %s", str, err)
+		}
+		trie.Insert(ipNet, value)
+	}
+
+	type trieTest struct {
+		ip       string
+		expected []uint64
+	}
+	testCases := []trieTest{
+		{"10.1.2.3", []uint64{1, 2}},
+		{"10.200.0.1", []uint64{1}},
+		{"192.168.1.20", []uint64{3}},
+		{"192.168.1.21", []uint64{}},
+		{"::ffff:10.1.2.3", []uint64{1, 2}},
+		{"2001:db8:1:2:abcd::1", []uint64{4, 5}},
+		{"2001:db8:ffff::1", []uint64{5}},
+		{"2001:db9::1", []uint64{}},
+	}
+	for _, testCase := range testCases {
+		result := trie.Match(net.ParseIP(testCase.ip))
+		matched := map[uint64]bool{}
+		for _, value := range result {
+			matched[value] = true
+		}
+		if len(matched) != len(testCase.expected) {
+			t.Errorf("trie match of '%s' => %v (expected %v)\n", testCase.ip, result, testCase.expected)
+			continue
+		}
+		for _, value := range testCase.expected {
+			if !matched[value] {
+				t.Errorf("trie match of '%s' => %v (expected %v)\n", testCase.ip, result, testCase.expected)
+				break
+			}
+		}
+	}
+
+}
+
+func TestGroupIpv6(t *testing.T) {
+	type groupTest struct {
+		input  string
+		output string
+	}
+	testCases := []groupTest{
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"2001:db8::/48", "2001:db8::/48"},
+		{"203.0.113.7", "203.0.113.7"},
+	}
+	for _, testCase := range testCases {
+		ipNet, err := ParseIpRange(testCase.input)
+		if err != nil {
+			t.Fatalf("failed to parse range '%s': %s", testCase.input, err)
+		}
+		result := IpRangeString(GroupIpv6(ipNet))
+		if result != testCase.output {
+			t.Errorf("incorrect IPv6 grouping of '%s' => '%s' (expected %s)\n", testCase.input, result, testCase.output)
+		}
+	}
+}
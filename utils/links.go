@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// obfuscationTlds are the top level domains recognized after a spelled out " dot ". Spelled out dots are common in
+// normal sentences, so they are only read as part of a link when followed by a familiar top level domain. Domains
+// that are also common words, like "to" and "me", are left out.
+var obfuscationTlds = map[string]bool{
+	"app": true, "biz": true, "cc": true, "club": true, "co": true, "com": true, "de": true, "dev": true,
+	"gg": true, "info": true, "io": true, "ly": true, "net": true, "online": true, "org": true, "ru": true,
+	"shop": true, "site": true, "tv": true, "uk": true, "xyz": true,
+}
+
+// linkDomainPattern is the pattern of a domain name: dot-separated labels, ending in a top level domain
+const linkDomainPattern = `(?:(?:[\p{L}\p{N}](?:[\p{L}\p{N}-]{0,61}[\p{L}\p{N}])?)\.)+(?:xn--[a-z0-9-]{1,59}|\p{L}{2,63})`
+
+var (
+	// linkBracketedDot matches dots hidden in brackets, like "example[.]com" or "example (dot) com"
+	linkBracketedDot = regexp.MustCompile(`(?i)\s*[(\[{<]\s*(?:dot|\.)\s*[)\]}>]\s*`)
+
+	// linkSpelledDot matches a spelled out dot between two words, like "example dot com"
+	linkSpelledDot = regexp.MustCompile(`(?i)([\p{L}\p{N}-]+)\s+dot\s+([\p{L}\p{N}-]+)`)
+
+	// linkDomain matches a domain name, with or without a scheme in front of it
+	linkDomain = regexp.MustCompile(`(?i)` + linkDomainPattern)
+
+	// linkSchemeHost matches the host of a URL with a scheme, which catches links to IP addresses
+	linkSchemeHost = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://\[?([^\s/?#\]]+)`)
+)
+
+// linkDotReplacer turns lookalike dots into plain dots
+var linkDotReplacer = strings.NewReplacer("。", ".", "．", ".", "｡", ".")
+
+// deobfuscateLinks rewrites the common ways of hiding a link in a message, so the links can be found
+func deobfuscateLinks(message string) string {
+	message = linkDotReplacer.Replace(message)
+	message = linkBracketedDot.ReplaceAllString(message, ".")
+	return linkSpelledDot.ReplaceAllStringFunc(message, func(match string) string {
+		parts := linkSpelledDot.FindStringSubmatch(match)
+		if !obfuscationTlds[strings.ToLower(parts[2])] {
+			return match
+		}
+		return parts[1] + "." + parts[2]
+	})
+}
+
+// NormalizeDomain gets the form of a domain used for comparisons, which is lowercase, without surrounding dots,
+// and with any punycode labels decoded
+func NormalizeDomain(domain string) string {
+	return DecodeDomain(strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."))
+}
+
+// FindLinkDomains finds the domains of all of the links in a message, including links that were obfuscated to get
+// past filters. The domains are normalized, and each one is only returned once.
+func FindLinkDomains(message string) []string {
+
+	// Undo any obfuscation of the links
+	message = deobfuscateLinks(message)
+
+	// Collect the hosts of URLs with a scheme, and any bare domain names
+	found := []string{}
+	for _, match := range linkSchemeHost.FindAllStringSubmatch(message, -1) {
+		host := match[1]
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		found = append(found, host)
+	}
+	found = append(found, linkDomain.FindAllString(message, -1)...)
+
+	// Normalize the domains, without any duplicates
+	seen := map[string]bool{}
+	domains := []string{}
+	for _, domain := range found {
+		domain = NormalizeDomain(domain)
+		if len(domain) == 0 || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	return domains
+
+}
+
+// DomainMatches checks if a domain is the listed domain, or one of its subdomains. Both should be normalized.
+func DomainMatches(domain string, listed string) bool {
+	return domain == listed || strings.HasSuffix(domain, "."+listed)
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindLinkDomains(t *testing.T) {
+	type linksTest struct {
+		message  string
+		expected []string
+	}
+	testCases := []linksTest{
+		// Messages without links
+		{"hello world", []string{}},
+		{"pi is 3.14, e.g. close enough...", []string{}},
+		{"connect the dot to the line", []string{}},
+		// Plain links and domains
+		{"check out https://www.Example.com/path?q=1", []string{"www.example.com"}},
+		{"go to example.com and example.com", []string{"example.com"}},
+		{"http://1.2.3.4:8080/login", []string{"1.2.3.4"}},
+		// Obfuscated links
+		{"visit example dot com", []string{"example.com"}},
+		{"visit example[.]com now", []string{"example.com"}},
+		{"visit example (dot) net", []string{"example.net"}},
+		{"visit example。com", []string{"example.com"}},
+		// Punycode is decoded, and unicode domains are found as they are
+		{"login at xn--80ak6aa92e.com", []string{"аррӏе.com"}},
+		{"login at аррӏе.com", []string{"аррӏе.com"}},
+	}
+	for _, testCase := range testCases {
+		domains := FindLinkDomains(testCase.message)
+		if !reflect.DeepEqual(domains, testCase.expected) {
+			t.Errorf("finding links in %q: expected %v, got %v", testCase.message, testCase.expected, domains)
+		}
+	}
+}
+
+func TestDomainMatches(t *testing.T) {
+	type matchTest struct {
+		domain   string
+		listed   string
+		expected bool
+	}
+	testCases := []matchTest{
+		{"example.com", "example.com", true},
+		{"www.example.com", "example.com", true},
+		{"badexample.com", "example.com", false},
+		{"example.com.evil.net", "example.com", false},
+	}
+	for _, testCase := range testCases {
+		if matches := DomainMatches(testCase.domain, testCase.listed); matches != testCase.expected {
+			t.Errorf("%s matching %s: expected %t", testCase.domain, testCase.listed, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"math/bits"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LeadingZeroBits counts the zero bits at the start of a hash
+func LeadingZeroBits(hash []byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+// proofOfWorkHash hashes a challenge together with a proposed solution
+func proofOfWorkHash(challenge string, solution string) []byte {
+	hash := sha256.Sum256([]byte(challenge + ":" + solution))
+	return hash[:]
+}
+
+// VerifyProofOfWork checks a hashcash-style solution to a challenge. The solution is valid if the SHA-256 hash of
+// "<challenge>:<solution>" starts with at least difficulty zero bits.
+func VerifyProofOfWork(challenge string, solution string, difficulty int) bool {
+	if len(solution) == 0 || len(solution) > 64 {
+		return false
+	}
+	return LeadingZeroBits(proofOfWorkHash(challenge, solution)) >= difficulty
+}
+
+// SolveProofOfWork finds the first solution to a challenge, counting up from zero. This is the work a client does
+// before it can chat.
+func SolveProofOfWork(challenge string, difficulty int) string {
+	for i := uint64(0); ; i++ {
+		solution := strconv.FormatUint(i, 10)
+		if VerifyProofOfWork(challenge, solution, difficulty) {
+			return solution
+		}
+	}
+}
+
+// ProofOfWorkDifficulty gets the difficulty of a challenge for a client that has made a number of recent requests.
+// Each doubling of the requests adds a bit, doubling the expected work, up to the maximum difficulty.
+func ProofOfWorkDifficulty(recentRequests int, baseDifficulty int, maxDifficulty int) int {
+	difficulty := baseDifficulty
+	if recentRequests > 1 {
+		difficulty += bits.Len(uint(recentRequests - 1))
+	}
+	if difficulty > maxDifficulty {
+		return maxDifficulty
+	}
+	return difficulty
+}
+
+// RateCounter counts the events for each key within a sliding window of time
+type RateCounter struct {
+	Window    time.Duration
+	events    map[string][]time.Time
+	lastSweep time.Time
+	mut       sync.Mutex
+}
+
+// Hit records an event for a key at the given time, and returns the number of events for the key within the
+// window, including this one
+func (c *RateCounter) Hit(key string, now time.Time) int {
+
+	// Lock on the counter
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// If the events map is nil, create it
+	if c.events == nil {
+		c.events = map[string][]time.Time{}
+	}
+
+	// Every so often, forget the keys that have gone quiet so the map doesn't grow forever
+	cutoff := now.Add(-c.Window)
+	if now.Sub(c.lastSweep) > c.Window {
+		for k, times := range c.events {
+			if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+				delete(c.events, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	// Drop the events for the key that have left the window, and add the new one
+	times := c.events[key]
+	start := 0
+	for start < len(times) && !times[start].After(cutoff) {
+		start++
+	}
+	times = append(times[start:], now)
+	c.events[key] = times
+	return len(times)
+
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeadingZeroBits(t *testing.T) {
+	type zeroBitsTest struct {
+		hash     []byte
+		expected int
+	}
+	testCases := []zeroBitsTest{
+		{[]byte{0xff}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x10}, 11},
+		{[]byte{0x00, 0x00}, 16},
+	}
+	for _, testCase := range testCases {
+		if count := LeadingZeroBits(testCase.hash); count != testCase.expected {
+			t.Errorf("leading zero bits of %x: expected %d, got %d", testCase.hash, testCase.expected, count)
+		}
+	}
+}
+
+func TestProofOfWork(t *testing.T) {
+
+	// Solving is deterministic, and the solution verifies
+	for _, difficulty := range []int{0, 4, 8, 12} {
+		solution := SolveProofOfWork("challenge", difficulty)
+		if solution != SolveProofOfWork("challenge", difficulty) {
+			t.Errorf("solving at difficulty %d is not deterministic", difficulty)
+		}
+		if !VerifyProofOfWork("challenge", solution, difficulty) {
+			t.Errorf("solution %q does not verify at difficulty %d", solution, difficulty)
+		}
+		if LeadingZeroBits(proofOfWorkHash("challenge", solution)) < difficulty {
+			t.Errorf("solution %q is not hard enough for difficulty %d", solution, difficulty)
+		}
+	}
+
+	// A solution doesn't verify at a higher difficulty than it meets
+	solution := SolveProofOfWork("challenge", 12)
+	zeroBits := LeadingZeroBits(proofOfWorkHash("challenge", solution))
+	if VerifyProofOfWork("challenge", solution, zeroBits+1) {
+		t.Errorf("solution %q verified above its difficulty", solution)
+	}
+
+	// Empty solutions are never valid
+	if VerifyProofOfWork("challenge", "", 0) {
+		t.Errorf("empty solution verified")
+	}
+
+}
+
+func TestProofOfWorkDifficulty(t *testing.T) {
+	type difficultyTest struct {
+		requests int
+		expected int
+	}
+	testCases := []difficultyTest{
+		{0, 16},
+		{1, 16},
+		{2, 17},
+		{4, 18},
+		{5, 19},
+		{100, 23},
+		{10000, 24},
+	}
+	for _, testCase := range testCases {
+		if difficulty := ProofOfWorkDifficulty(testCase.requests, 16, 24); difficulty != testCase.expected {
+			t.Errorf("difficulty after %d requests: expected %d, got %d", testCase.requests, testCase.expected, difficulty)
+		}
+	}
+}
+
+func TestRateCounter(t *testing.T) {
+	counter := RateCounter{Window: time.Minute}
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Events within the window add up, per key
+	if count := counter.Hit("a", start); count != 1 {
+		t.Errorf("expected 1 event, got %d", count)
+	}
+	if count := counter.Hit("a", start.Add(10*time.Second)); count != 2 {
+		t.Errorf("expected 2 events, got %d", count)
+	}
+	if count := counter.Hit("b", start.Add(20*time.Second)); count != 1 {
+		t.Errorf("expected 1 event for another key, got %d", count)
+	}
+
+	// Events that have left the window are dropped
+	if count := counter.Hit("a", start.Add(65*time.Second)); count != 2 {
+		t.Errorf("expected 2 events after the window slid, got %d", count)
+	}
+	if count := counter.Hit("a", start.Add(5*time.Minute)); count != 1 {
+		t.Errorf("expected 1 event after the window passed, got %d", count)
+	}
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Parameters of the punycode encoding, from RFC 3492
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+)
+
+// punycodePrefix marks a domain label as punycode encoded
+const punycodePrefix = "xn--"
+
+var errInvalidPunycode = errors.New("invalid punycode")
+
+// punycodeAdapt adapts the bias after decoding each character
+func punycodeAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+// punycodeDigit gets the value of a single punycode digit, or -1 if it isn't one
+func punycodeDigit(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a')
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A')
+	}
+	return -1
+}
+
+// DecodePunycode decodes a punycode string, without the "xn--" prefix, into unicode
+func DecodePunycode(input string) (string, error) {
+
+	// Everything before the last delimiter is copied as it is
+	output := []rune{}
+	if b := strings.LastIndexByte(input, '-'); b >= 0 {
+		for i := 0; i < b; i++ {
+			if input[i] >= 0x80 {
+				return "", errInvalidPunycode
+			}
+			output = append(output, rune(input[i]))
+		}
+		input = input[b+1:]
+	}
+
+	// Decode each of the encoded characters, inserting them into the output
+	n := punycodeInitialN
+	bias := punycodeInitialBias
+	i := 0
+	for pos := 0; pos < len(input); {
+
+		// Read the variable length integer for the next insertion
+		oldi := i
+		w := 1
+		for k := punycodeBase; ; k += punycodeBase {
+			if pos >= len(input) {
+				return "", errInvalidPunycode
+			}
+			digit := punycodeDigit(input[pos])
+			pos++
+			if digit < 0 || digit > (math.MaxInt32-i)/w {
+				return "", errInvalidPunycode
+			}
+			i += digit * w
+			t := k - bias
+			if t < punycodeTMin {
+				t = punycodeTMin
+			} else if t > punycodeTMax {
+				t = punycodeTMax
+			}
+			if digit < t {
+				break
+			}
+			w *= punycodeBase - t
+		}
+
+		// Work out the character and where it goes
+		bias = punycodeAdapt(i-oldi, len(output)+1, oldi == 0)
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n > 0x10FFFF {
+			return "", errInvalidPunycode
+		}
+
+		// Insert the character
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = rune(n)
+		i++
+
+	}
+	return string(output), nil
+
+}
+
+// DecodeDomain converts the punycode labels of a domain name into unicode, so a domain can be compared in the
+// same form no matter how it was written. Labels that aren't valid punycode are left as they are.
+func DecodeDomain(domain string) string {
+	labels := strings.Split(strings.ToLower(domain), ".")
+	for i, label := range labels {
+		if !strings.HasPrefix(label, punycodePrefix) {
+			continue
+		}
+		if decoded, err := DecodePunycode(label[len(punycodePrefix):]); err == nil {
+			labels[i] = decoded
+		}
+	}
+	return strings.Join(labels, ".")
+}
@@ -0,0 +1,55 @@
+package utils
+
+import "testing"
+
+func TestDecodePunycode(t *testing.T) {
+	type punycodeTest struct {
+		input    string
+		expected string
+	}
+	testCases := []punycodeTest{
+		{"bcher-kva", "bücher"},
+		{"mnchen-3ya", "münchen"},
+		{"80ak6aa92e", "аррӏе"},
+		{"r8jz45g", "例え"},
+		{"eckwd4c7cu47r2wf", "ドメイン名例"},
+		{"fa-hia", "faß"},
+		{"abc-", "abc"},
+	}
+	for _, testCase := range testCases {
+		decoded, err := DecodePunycode(testCase.input)
+		if err != nil {
+			t.Errorf("failed to decode %q: %s", testCase.input, err)
+			continue
+		}
+		if decoded != testCase.expected {
+			t.Errorf("decoding %q: expected %q, got %q", testCase.input, testCase.expected, decoded)
+		}
+	}
+
+	// Invalid input is rejected
+	for _, input := range []string{"bcher-kv", "ab!c", "bücher-kva"} {
+		if _, err := DecodePunycode(input); err == nil {
+			t.Errorf("expected an error decoding %q", input)
+		}
+	}
+}
+
+func TestDecodeDomain(t *testing.T) {
+	type domainTest struct {
+		domain   string
+		expected string
+	}
+	testCases := []domainTest{
+		{"example.com", "example.com"},
+		{"XN--BCHER-KVA.example", "bücher.example"},
+		{"www.xn--80ak6aa92e.com", "www.аррӏе.com"},
+		// Invalid punycode is left alone
+		{"xn--!!.com", "xn--!!.com"},
+	}
+	for _, testCase := range testCases {
+		if domain := DecodeDomain(testCase.domain); domain != testCase.expected {
+			t.Errorf("decoding %q: expected %q, got %q", testCase.domain, testCase.expected, domain)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// isJoiningMark checks if a rune only modifies the rune before it, like a combining accent or the joiners and
+// variation selectors inside emoji sequences. These aren't counted as characters of their own.
+func isJoiningMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)
+}
+
+// CapsRatio gets the fraction of the letters in a message that are uppercase, along with the number of letters
+// that have a case at all
+func CapsRatio(message string) (float64, int) {
+	var letters, upper int
+	for _, r := range message {
+		if !unicode.IsUpper(r) && !unicode.IsLower(r) {
+			continue
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	if letters == 0 {
+		return 0, 0
+	}
+	return float64(upper) / float64(letters), letters
+}
+
+// SymbolRatio gets the fraction of the visible characters in a message that are symbols, punctuation or emoji
+// rather than letters and numbers, along with the number of visible characters
+func SymbolRatio(message string) (float64, int) {
+	var chars, symbols int
+	for _, r := range message {
+		if unicode.IsSpace(r) || isJoiningMark(r) {
+			continue
+		}
+		chars++
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			symbols++
+		}
+	}
+	if chars == 0 {
+		return 0, 0
+	}
+	return float64(symbols) / float64(chars), chars
+}
+
+// LongestRun gets the length of the longest run of the same character in a message, ignoring case
+func LongestRun(message string) int {
+	var longest, current int
+	var last rune = -1
+	for _, r := range message {
+		r = unicode.ToLower(r)
+		if r == last {
+			current++
+		} else {
+			current = 1
+			last = r
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// MessageLength gets the number of characters in a message
+func MessageLength(message string) int {
+	return utf8.RuneCountInString(message)
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCapsRatio(t *testing.T) {
+	type capsTest struct {
+		message string
+		ratio   float64
+		letters int
+	}
+	testCases := []capsTest{
+		{"hello world", 0, 10},
+		{"HELLO world", 0.5, 10},
+		{"HELLO WORLD!!!", 1, 10},
+		{"123 !!!", 0, 0},
+		{"ÉCOLE", 1, 5},
+	}
+	for _, testCase := range testCases {
+		ratio, letters := CapsRatio(testCase.message)
+		if math.Abs(ratio-testCase.ratio) > 0.0001 || letters != testCase.letters {
+			t.Errorf(
+				"caps ratio of %q: expected %f over %d letters, got %f over %d",
+				testCase.message,
+				testCase.ratio,
+				testCase.letters,
+				ratio,
+				letters,
+			)
+		}
+	}
+}
+
+func TestSymbolRatio(t *testing.T) {
+	type symbolTest struct {
+		message string
+		ratio   float64
+		chars   int
+	}
+	testCases := []symbolTest{
+		{"hello world", 0, 10},
+		{"hi!!", 0.5, 4},
+		{"😀😀😀😀", 1, 4},
+		// Joiners and variation selectors inside emoji sequences aren't counted
+		{"❤️❤️", 1, 2},
+		{"   ", 0, 0},
+	}
+	for _, testCase := range testCases {
+		ratio, chars := SymbolRatio(testCase.message)
+		if math.Abs(ratio-testCase.ratio) > 0.0001 || chars != testCase.chars {
+			t.Errorf(
+				"symbol ratio of %q: expected %f over %d characters, got %f over %d",
+				testCase.message,
+				testCase.ratio,
+				testCase.chars,
+				ratio,
+				chars,
+			)
+		}
+	}
+}
+
+func TestLongestRun(t *testing.T) {
+	type runTest struct {
+		message  string
+		expected int
+	}
+	testCases := []runTest{
+		{"", 0},
+		{"abc", 1},
+		{"hello", 2},
+		{"nooooooo", 7},
+		{"NoOoOo", 5},
+		{"!!!!!!", 6},
+		{"😀😀😀", 3},
+	}
+	for _, testCase := range testCases {
+		if run := LongestRun(testCase.message); run != testCase.expected {
+			t.Errorf("longest run of %q: expected %d, got %d", testCase.message, testCase.expected, run)
+		}
+	}
+}
+
+func TestMessageLength(t *testing.T) {
+	if length := MessageLength("héllo 😀"); length != 7 {
+		t.Errorf("expected length 7, got %d", length)
+	}
+}
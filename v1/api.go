@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/connerdouglass/livechat-api/services"
+	coreutils "github.com/connerdouglass/livechat-api/utils"
 	"github.com/connerdouglass/livechat-api/v1/hooks"
 	"github.com/connerdouglass/livechat-api/v1/middleware"
 	"github.com/gin-gonic/gin"
@@ -9,15 +10,23 @@ import (
 
 // Server is the API server instance
 type Server struct {
-	AccountsService   *services.AccountsService
-	AuthTokensService *services.AuthTokensService
-	ChatService       *services.ChatService
+	IpResolver           *coreutils.IpResolver
+	AccountsService      *services.AccountsService
+	AssetsService        *services.AssetsService
+	AuthTokensService    *services.AuthTokensService
+	ChatService          *services.ChatService
+	ModerationService    *services.ModerationService
+	OrganizationsService *services.OrganizationsService
+	PrivacyService       *services.PrivacyService
+	RetentionService     *services.RetentionService
+	SocketsService       *services.SocketsService
 }
 
 // Setup mounts the API server to the given group
 func (s *Server) Setup(g *gin.RouterGroup) {
 
 	// Register middleware for all routes
+	g.Use(middleware.ResolveIpAddress(s.IpResolver))
 	g.Use(middleware.CheckAuth(s.AuthTokensService))
 
 	// Register all of the public hooks that require no authentication
@@ -37,6 +46,9 @@ func (s *Server) setupPublicHooks(g *gin.RouterGroup) {
 		s.AccountsService,
 		s.AuthTokensService,
 	))
+	g.GET("/assets/*key", hooks.Assets(
+		s.AssetsService,
+	))
 
 }
 
@@ -50,13 +62,173 @@ func (s *Server) setupAuthenticatedHooks(g *gin.RouterGroup) {
 	g.POST("/auth/whoami", hooks.AuthWhoAmI(
 		s.AuthTokensService,
 	))
+	g.POST("/auth/update-profile", hooks.AuthUpdateProfile(
+		s.AccountsService,
+		s.AuthTokensService,
+		s.ChatService,
+	))
 	g.POST("/studio/chat/mute", hooks.StudioChatMute(
 		s.AccountsService,
 		s.ChatService,
+		s.ModerationService,
+		s.OrganizationsService,
+		s.SocketsService,
 	))
 	g.POST("/studio/chat/unmute", hooks.StudioChatUnmute(
 		s.AccountsService,
 		s.ChatService,
+		s.ModerationService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/chat/mutes", hooks.StudioChatMutes(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/chat/mutes/update", hooks.StudioChatMutesUpdate(
+		s.ChatService,
+		s.ModerationService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/chat/held", hooks.StudioChatHeld(
+		s.OrganizationsService,
+		s.SocketsService,
+	))
+	g.POST("/studio/chat/held/approve", hooks.StudioChatHeldApprove(
+		s.ChatService,
+		s.OrganizationsService,
+		s.SocketsService,
+	))
+	g.POST("/studio/chat/held/reject", hooks.StudioChatHeldReject(
+		s.ChatService,
+		s.OrganizationsService,
+		s.SocketsService,
+	))
+	g.POST("/studio/chat/room/update", hooks.StudioChatRoomUpdate(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/chat/user", hooks.StudioChatUser(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/chat/pin", hooks.StudioChatPin(
+		s.ChatService,
+		s.OrganizationsService,
+		s.SocketsService,
+	))
+	g.POST("/studio/chat/unpin", hooks.StudioChatUnpin(
+		s.ChatService,
+		s.OrganizationsService,
+		s.SocketsService,
+	))
+	g.POST("/studio/chat/reactions", hooks.StudioChatReactions(
+		s.OrganizationsService,
+	))
+	g.POST("/studio/chat/reactions/update", hooks.StudioChatReactionsUpdate(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/chat/clear", hooks.StudioChatClear(
+		s.ChatService,
+		s.ModerationService,
+		s.OrganizationsService,
+		s.SocketsService,
+	))
+	g.POST("/studio/automod/links", hooks.StudioAutomodLinks(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/automod/links/update", hooks.StudioAutomodLinksUpdate(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/automod/reserved-names", hooks.StudioAutomodReservedNames(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/automod/reserved-names/add", hooks.StudioAutomodReservedNamesAdd(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/automod/reserved-names/delete", hooks.StudioAutomodReservedNamesDelete(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/automod/spam-rules", hooks.StudioAutomodSpamRules(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/automod/spam-rules/save", hooks.StudioAutomodSpamRulesSave(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/automod/spam-rules/delete", hooks.StudioAutomodSpamRulesDelete(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/badges", hooks.StudioBadges(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/badges/save", hooks.StudioBadgesSave(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/badges/image", hooks.StudioBadgesImage(
+		s.AssetsService,
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/badges/delete", hooks.StudioBadgesDelete(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/badges/assign", hooks.StudioBadgesAssign(
+		s.AccountsService,
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/badges/unassign", hooks.StudioBadgesUnassign(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/emotes", hooks.StudioEmotes(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/emotes/upload", hooks.StudioEmotesUpload(
+		s.AssetsService,
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/emotes/delete", hooks.StudioEmotesDelete(
+		s.ChatService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/moderation/events", hooks.StudioModerationEvents(
+		s.ModerationService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/moderation/events/export", hooks.StudioModerationEventsExport(
+		s.ModerationService,
+		s.OrganizationsService,
+	))
+	g.POST("/studio/privacy/export", hooks.StudioPrivacyExport(
+		s.OrganizationsService,
+		s.PrivacyService,
+	))
+	g.POST("/studio/privacy/erase", hooks.StudioPrivacyErase(
+		s.ModerationService,
+		s.OrganizationsService,
+		s.PrivacyService,
+		s.SocketsService,
+	))
+	g.POST("/studio/privacy/retention", hooks.StudioPrivacyRetention(
+		s.OrganizationsService,
+	))
+	g.POST("/studio/privacy/retention/update", hooks.StudioPrivacyRetentionUpdate(
+		s.OrganizationsService,
+		s.RetentionService,
 	))
 
 }
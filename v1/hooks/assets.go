@@ -0,0 +1,55 @@
+package hooks
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/connerdouglass/livechat-api/services"
+	coreutils "github.com/connerdouglass/livechat-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// assetCacheControl lets browsers and proxies keep assets for a year. Asset keys change whenever the content does,
+// so a cached asset never goes stale.
+const assetCacheControl = "public, max-age=31536000, immutable"
+
+// Assets serves a stored asset, like a badge image, by its key
+func Assets(
+	assetsService *services.AssetsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the key of the asset
+		key, err := coreutils.CleanBlobKey(strings.TrimPrefix(c.Param("key"), "/"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "asset not found"})
+			return
+		}
+
+		// The content never changes, so a client holding any copy of it is up to date
+		etag := "\"" + key + "\""
+		c.Header("Cache-Control", assetCacheControl)
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		// Get the asset
+		data, err := assetsService.GetAsset(key)
+		if err != nil {
+			c.Header("Cache-Control", "no-store")
+			if errors.Is(err, coreutils.ErrBlobNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "asset not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serve the asset
+		c.Data(http.StatusOK, http.DetectContentType(data), data)
+
+	}
+}
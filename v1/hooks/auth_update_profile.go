@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AuthUpdateProfileReq struct {
+	DisplayName string `json:"display_name"`
+}
+
+func AuthUpdateProfile(
+	accountsService *services.AccountsService,
+	authTokensService *services.AuthTokensService,
+	chatService *services.ChatService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req AuthUpdateProfileReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Update the display name of the account
+		account := utils.CtxGetAccount(c)
+		if err := accountsService.UpdateDisplayName(account, req.DisplayName); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// The display name is protected from impersonation in every organization the account belongs to
+		chatService.InvalidateAllProtectedNames()
+
+		// Return the updated whoami info
+		whoami, err := serializeWhoAmI(
+			account,
+			authTokensService,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data": whoami,
+		})
+
+	}
+}
@@ -59,8 +59,9 @@ func serializeWhoAmI(
 
 	// Return the map of whoami info
 	return map[string]interface{}{
-		"id":    account.ID,
-		"email": account.Email,
-		"token": token,
+		"id":           account.ID,
+		"email":        account.Email,
+		"display_name": account.DisplayName,
+		"token":        token,
 	}, nil
 }
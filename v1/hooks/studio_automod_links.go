@@ -0,0 +1,83 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioAutomodLinksReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+}
+
+func serializeLinkPolicy(organization *models.Organization, linkDomains []*models.LinkDomain) map[string]interface{} {
+	allowDomains := []string{}
+	blockDomains := []string{}
+	for _, linkDomain := range linkDomains {
+		if linkDomain.Allowed {
+			allowDomains = append(allowDomains, linkDomain.Domain)
+		} else {
+			blockDomains = append(blockDomains, linkDomain.Domain)
+		}
+	}
+	return map[string]interface{}{
+		"organization_id": organization.ID,
+		"policy":          organization.LinkPolicy,
+		"allow_domains":   allowDomains,
+		"block_domains":   blockDomains,
+	}
+}
+
+func StudioAutomodLinks(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioAutomodLinksReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get the organization
+		organization, err := organizationsService.GetOrganizationByID(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if organization == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+
+		// Get the domain lists
+		linkDomains, err := chatService.GetLinkDomains(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the link policy
+		c.JSON(http.StatusOK, gin.H{
+			"data": serializeLinkPolicy(organization, linkDomains),
+		})
+
+	}
+}
@@ -0,0 +1,92 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioAutomodLinksUpdateReq struct {
+	OrganizationID uint64   `json:"organization_id"`
+	Policy         *string  `json:"policy"`
+	AllowDomains   []string `json:"allow_domains"`
+	BlockDomains   []string `json:"block_domains"`
+}
+
+func StudioAutomodLinksUpdate(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioAutomodLinksUpdateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Policy != nil && !models.IsValidLinkPolicy(*req.Policy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid link policy"})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the organization
+		organization, err := organizationsService.GetOrganizationByID(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if organization == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+
+		// Update the policy, and replace whichever domain lists were provided
+		if req.Policy != nil {
+			if err := chatService.UpdateLinkPolicy(organization, *req.Policy); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if req.AllowDomains != nil {
+			if err := chatService.SetLinkDomains(organization.ID, true, req.AllowDomains); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if req.BlockDomains != nil {
+			if err := chatService.SetLinkDomains(organization.ID, false, req.BlockDomains); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// Get the new domain lists
+		linkDomains, err := chatService.GetLinkDomains(organization.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the new link policy
+		c.JSON(http.StatusOK, gin.H{
+			"data": serializeLinkPolicy(organization, linkDomains),
+		})
+
+	}
+}
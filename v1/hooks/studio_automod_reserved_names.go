@@ -0,0 +1,61 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioAutomodReservedNamesReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+}
+
+func StudioAutomodReservedNames(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioAutomodReservedNamesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get the reserved names
+		reservedNames, err := chatService.GetReservedNames(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serialize the reserved names
+		reservedNamesSer := make([]map[string]interface{}, len(reservedNames))
+		for i, reservedName := range reservedNames {
+			reservedNamesSer[i] = services.SerializeReservedName(reservedName)
+		}
+
+		// Return the reserved names
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"reserved_names": reservedNamesSer,
+			},
+		})
+
+	}
+}
@@ -0,0 +1,55 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioAutomodReservedNamesAddReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+	Name           string `json:"name"`
+}
+
+func StudioAutomodReservedNamesAdd(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioAutomodReservedNamesAddReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Reserve the name
+		reservedName, err := chatService.AddReservedName(req.OrganizationID, req.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the reserved name
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeReservedName(reservedName),
+		})
+
+	}
+}
@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioAutomodSpamRulesDeleteReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+	ID             uint64 `json:"id"`
+}
+
+func StudioAutomodSpamRulesDelete(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioAutomodSpamRulesDeleteReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the rule
+		rule, err := chatService.GetSpamRuleByID(req.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if rule == nil || rule.OrganizationID != req.OrganizationID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "spam rule not found"})
+			return
+		}
+
+		// Delete the rule
+		if err := chatService.DeleteSpamRule(rule); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Otherwise return something successfully
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{},
+		})
+
+	}
+}
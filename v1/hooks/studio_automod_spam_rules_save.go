@@ -0,0 +1,92 @@
+package hooks
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioAutomodSpamRulesSaveReq struct {
+	OrganizationID uint64  `json:"organization_id"`
+	ID             uint64  `json:"id"`
+	Heuristic      string  `json:"heuristic"`
+	Threshold      float64 `json:"threshold"`
+	MinLength      int     `json:"min_length"`
+	WindowSeconds  int64   `json:"window_seconds"`
+	Action         string  `json:"action"`
+	TimeoutSeconds *int64  `json:"timeout_seconds"`
+}
+
+func StudioAutomodSpamRulesSave(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioAutomodSpamRulesSaveReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the existing rule, or start a new one
+		rule := &models.SpamRule{OrganizationID: req.OrganizationID}
+		if req.ID > 0 {
+			rule, err = chatService.GetSpamRuleByID(req.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if rule == nil || rule.OrganizationID != req.OrganizationID {
+				c.JSON(http.StatusNotFound, gin.H{"error": "spam rule not found"})
+				return
+			}
+		}
+
+		// Apply the settings to the rule
+		rule.Heuristic = req.Heuristic
+		rule.Threshold = req.Threshold
+		rule.MinLength = req.MinLength
+		rule.WindowSeconds = req.WindowSeconds
+		rule.Action = req.Action
+		rule.TimeoutSeconds = sql.NullInt64{}
+		if req.TimeoutSeconds != nil {
+			rule.TimeoutSeconds = sql.NullInt64{Valid: true, Int64: *req.TimeoutSeconds}
+		}
+
+		// Make sure the settings are valid
+		if err := services.ValidateSpamRule(rule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Save the rule
+		if err := chatService.SaveSpamRule(rule); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the saved rule
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeSpamRule(rule),
+		})
+
+	}
+}
@@ -0,0 +1,75 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioBadgesReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+}
+
+func StudioBadges(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioBadgesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get the badges
+		badges, err := chatService.GetBadges(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serialize the badges
+		badgesSer := make([]map[string]interface{}, len(badges))
+		for i, badge := range badges {
+			badgesSer[i] = services.SerializeBadge(badge)
+		}
+
+		// Get the assignments of the badges
+		assignments, err := chatService.GetBadgeAssignments(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serialize the assignments
+		assignmentsSer := make([]map[string]interface{}, len(assignments))
+		for i, assignment := range assignments {
+			assignmentsSer[i] = services.SerializeBadgeAssignment(assignment)
+		}
+
+		// Return the badges and their assignments
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"badges":      badgesSer,
+				"assignments": assignmentsSer,
+			},
+		})
+
+	}
+}
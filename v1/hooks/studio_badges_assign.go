@@ -0,0 +1,109 @@
+package hooks
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioBadgesAssignReq struct {
+	OrganizationID     uint64 `json:"organization_id"`
+	BadgeID            uint64 `json:"badge_id"`
+	Username           string `json:"username"`
+	AccountEmail       string `json:"account_email"`
+	ChatRoomIdentifier string `json:"chat_room_identifier"`
+	Role               string `json:"role"`
+}
+
+func StudioBadgesAssign(
+	accountsService *services.AccountsService,
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioBadgesAssignReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the badge
+		badge, err := chatService.GetBadgeByID(req.BadgeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if badge == nil || badge.OrganizationID != req.OrganizationID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "badge not found"})
+			return
+		}
+
+		// Create the assignment
+		assignment := &models.BadgeAssignment{
+			OrganizationID: req.OrganizationID,
+			BadgeID:        badge.ID,
+			Username:       sql.NullString{Valid: len(req.Username) > 0, String: req.Username},
+			Role:           req.Role,
+		}
+
+		// Find the account the badge is assigned to, if any
+		if len(req.AccountEmail) > 0 {
+			badgeAccount, err := accountsService.GetAccountByEmail(req.AccountEmail)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if badgeAccount == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+				return
+			}
+			assignment.AccountID = sql.NullInt64{Valid: true, Int64: int64(badgeAccount.ID)}
+			assignment.Account = badgeAccount
+		}
+
+		// Find the chat room the badge is limited to, if any
+		if len(req.ChatRoomIdentifier) > 0 {
+			chatRoom, err := chatService.GetChatRoomByIdentifier(req.ChatRoomIdentifier)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if chatRoom == nil || chatRoom.OrganizationID != req.OrganizationID {
+				c.JSON(http.StatusNotFound, gin.H{"error": "chat room not found"})
+				return
+			}
+			assignment.ChatRoomID = sql.NullInt64{Valid: true, Int64: int64(chatRoom.ID)}
+			assignment.ChatRoom = chatRoom
+		}
+
+		// Assign the badge
+		if err := chatService.AssignBadge(assignment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the assignment
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeBadgeAssignment(assignment),
+		})
+
+	}
+}
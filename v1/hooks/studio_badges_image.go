@@ -0,0 +1,106 @@
+package hooks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImageFormBytes is the largest upload form accepted, leaving room for the other form fields
+const maxImageFormBytes = services.MaxImageUploadBytes + 64*1024
+
+// readFormImage reads the file uploaded in the image field of a multipart form
+func readFormImage(c *gin.Context) ([]byte, error) {
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return nil, errors.New("image is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// StudioBadgesImage uploads a new image for a badge. Unlike the other hooks, it takes a multipart form with the
+// organization_id and badge_id fields, and the image in the image field.
+func StudioBadgesImage(
+	assetsService *services.AssetsService,
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Limit the size of the upload
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageFormBytes)
+
+		// Get the fields of the form
+		organizationID, err := strconv.ParseUint(c.PostForm("organization_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization_id"})
+			return
+		}
+		badgeID, err := strconv.ParseUint(c.PostForm("badge_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid badge_id"})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, organizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the badge
+		badge, err := chatService.GetBadgeByID(badgeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if badge == nil || badge.OrganizationID != organizationID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "badge not found"})
+			return
+		}
+
+		// Read the uploaded image
+		data, err := readFormImage(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Resize and store the image
+		imageURL, err := assetsService.StoreBadgeImage(data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Point the badge at the new image
+		badge.Image = imageURL
+		if err := chatService.SaveBadge(badge); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the updated badge
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeBadge(badge),
+		})
+
+	}
+}
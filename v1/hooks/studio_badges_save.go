@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioBadgesSaveReq struct {
+	OrganizationID  uint64 `json:"organization_id"`
+	ID              uint64 `json:"id"`
+	Name            string `json:"name"`
+	BackgroundColor string `json:"background_color"`
+	Image           string `json:"image"`
+}
+
+func StudioBadgesSave(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioBadgesSaveReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the existing badge, or start a new one
+		badge := &models.Badge{OrganizationID: req.OrganizationID}
+		if req.ID > 0 {
+			badge, err = chatService.GetBadgeByID(req.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if badge == nil || badge.OrganizationID != req.OrganizationID {
+				c.JSON(http.StatusNotFound, gin.H{"error": "badge not found"})
+				return
+			}
+		}
+
+		// Apply the settings to the badge
+		badge.Name = req.Name
+		badge.BackgroundColor = req.BackgroundColor
+		badge.Image = req.Image
+
+		// Make sure the settings are valid
+		if err := services.ValidateBadge(badge); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Save the badge
+		if err := chatService.SaveBadge(badge); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the saved badge
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeBadge(badge),
+		})
+
+	}
+}
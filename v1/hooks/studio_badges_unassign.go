@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioBadgesUnassignReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+	ID             uint64 `json:"id"`
+}
+
+func StudioBadgesUnassign(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioBadgesUnassignReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the assignment
+		assignment, err := chatService.GetBadgeAssignmentByID(req.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if assignment == nil || assignment.OrganizationID != req.OrganizationID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "badge assignment not found"})
+			return
+		}
+
+		// Take the badge away
+		if err := chatService.DeleteBadgeAssignment(assignment); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Otherwise return something successfully
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{},
+		})
+
+	}
+}
@@ -0,0 +1,78 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatClearReq struct {
+	ChatRoomIdentifier string `json:"chat_room_identifier"`
+}
+
+func StudioChatClear(
+	chatService *services.ChatService,
+	moderationService *services.ModerationService,
+	organizationsService *services.OrganizationsService,
+	socketsService *services.SocketsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatClearReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Get the chat room
+		chatRoom, err := chatService.GetChatRoomByIdentifier(req.ChatRoomIdentifier)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if chatRoom == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "chat room not found"})
+			return
+		}
+
+		// Make sure the account can moderate the chat room
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, chatRoom.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this chat room"})
+			return
+		}
+
+		// Clear all the messages from the chat room
+		if err := socketsService.ClearChatRoom(chatRoom); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Record the action in the moderation log
+		_, err = moderationService.RecordEvent(&services.ModerationEventInfo{
+			OrganizationID: chatRoom.OrganizationID,
+			ChatRoom:       chatRoom,
+			Actor:          services.AccountActor(account),
+			Action:         models.ModerationActionClear,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Otherwise return something successfully
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{},
+		})
+
+	}
+}
@@ -0,0 +1,59 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatHeldReq struct {
+	OrganizationID     uint64 `json:"organization_id"`
+	ChatRoomIdentifier string `json:"chat_room_identifier"`
+}
+
+func StudioChatHeld(
+	organizationsService *services.OrganizationsService,
+	socketsService *services.SocketsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatHeldReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get the held messages, only keeping the chat room requested if there is one
+		heldMessages := socketsService.GetHeldMessages(req.OrganizationID)
+		messagesSer := []map[string]interface{}{}
+		for _, held := range heldMessages {
+			if len(req.ChatRoomIdentifier) > 0 && held.ChatRoomIdentifier != req.ChatRoomIdentifier {
+				continue
+			}
+			messagesSer = append(messagesSer, services.SerializeHeldMessage(held))
+		}
+
+		// Return the held messages
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"messages": messagesSer,
+			},
+		})
+
+	}
+}
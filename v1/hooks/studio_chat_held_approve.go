@@ -0,0 +1,66 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatHeldReviewReq struct {
+	ChatRoomIdentifier string `json:"chat_room_identifier"`
+	MessageID          string `json:"message_id"`
+	Reason             string `json:"reason"`
+}
+
+func StudioChatHeldApprove(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+	socketsService *services.SocketsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatHeldReviewReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Get the chat room
+		chatRoom, err := chatService.GetChatRoomByIdentifier(req.ChatRoomIdentifier)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if chatRoom == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "chat room not found"})
+			return
+		}
+
+		// Make sure the account can moderate the chat room
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, chatRoom.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this chat room"})
+			return
+		}
+
+		// Approve the held message
+		if err := socketsService.ApproveHeldMessage(chatRoom, req.MessageID, services.AccountActor(account)); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Otherwise return something successfully
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{},
+		})
+
+	}
+}
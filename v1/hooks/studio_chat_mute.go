@@ -3,18 +3,28 @@ package hooks
 import (
 	"net/http"
 
+	"github.com/connerdouglass/livechat-api/models"
 	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
 	"github.com/gin-gonic/gin"
 )
 
 type StudioChatMuteReq struct {
-	OrganizationID uint64                `json:"organization_id"`
-	User           services.ChatUserInfo `json:"user"`
+	OrganizationID     uint64                `json:"organization_id"`
+	ChatRoomIdentifier string                `json:"chat_room_identifier"`
+	User               services.ChatUserInfo `json:"user"`
+	Reason             string                `json:"reason"`
+	Purge              bool                  `json:"purge"`
+	Shadow             bool                  `json:"shadow"`
+	GroupIpv6          bool                  `json:"group_ipv6"`
 }
 
 func StudioChatMute(
 	accountsService *services.AccountsService,
 	chatService *services.ChatService,
+	moderationService *services.ModerationService,
+	organizationsService *services.OrganizationsService,
+	socketsService *services.SocketsService,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -25,11 +35,84 @@ func StudioChatMute(
 			return
 		}
 
-		// Get the account sending the request
-		// account := utils.CtxGetAccount(c)
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Make sure the IP address or range is valid to be muted
+		if len(req.User.IpAddress) > 0 {
+			ipAddress, err := services.NormalizeMuteIpAddress(req.User.IpAddress, req.GroupIpv6)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			req.User.IpAddress = ipAddress
+		}
+
+		// Make sure the user's messages can be found, before muting them, if they are to be purged
+		if req.Purge {
+			if err := chatService.ValidatePurge(&req.User); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// If a chat room is provided, the mute only applies to that chat room
+		opts := services.MuteOptions{
+			Reason:    req.Reason,
+			Shadow:    req.Shadow,
+			GroupIpv6: req.GroupIpv6,
+		}
+		if len(req.ChatRoomIdentifier) > 0 {
+			chatRoom, err := chatService.GetChatRoomByIdentifier(req.ChatRoomIdentifier)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if chatRoom == nil || chatRoom.OrganizationID != req.OrganizationID {
+				c.JSON(http.StatusNotFound, gin.H{"error": "chat room not found"})
+				return
+			}
+			opts.ChatRoom = chatRoom
+		}
 
 		// Mute the user on the chat
-		if _, err := chatService.MuteUser(req.OrganizationID, &req.User, nil); err != nil {
+		if _, err := chatService.MuteUser(req.OrganizationID, &req.User, &opts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Remove the user's earlier messages from chat if requested
+		if req.Purge {
+			if opts.ChatRoom != nil {
+				_, err = socketsService.PurgeUserMessages(opts.ChatRoom, &req.User)
+			} else {
+				err = socketsService.PurgeOrganizationUserMessages(req.OrganizationID, &req.User)
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// Record the action in the moderation log
+		_, err = moderationService.RecordEvent(&services.ModerationEventInfo{
+			OrganizationID: req.OrganizationID,
+			ChatRoom:       opts.ChatRoom,
+			Actor:          services.AccountActor(account),
+			Action:         models.ModerationActionMute,
+			Target:         &req.User,
+			Reason:         req.Reason,
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -0,0 +1,84 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatMutesReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+	ChatRoomID     uint64 `json:"chat_room_id"`
+	Status         string `json:"status"`
+	Search         string `json:"search"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+}
+
+func StudioChatMutes(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatMutesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Keep the page size within reason
+		if req.Limit <= 0 || req.Limit > 100 {
+			req.Limit = 50
+		}
+		if req.Offset < 0 {
+			req.Offset = 0
+		}
+
+		// Get the page of mutes
+		mutes, total, err := chatService.ListMutes(&services.MuteFilter{
+			OrganizationID: req.OrganizationID,
+			ChatRoomID:     req.ChatRoomID,
+			Status:         req.Status,
+			Search:         req.Search,
+			Offset:         req.Offset,
+			Limit:          req.Limit,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serialize all of the mutes
+		mutesSer := make([]map[string]interface{}, len(mutes))
+		for i, mute := range mutes {
+			mutesSer[i] = services.SerializeMutedUser(mute)
+		}
+
+		// Return the page of mutes
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"mutes":  mutesSer,
+				"total":  total,
+				"offset": req.Offset,
+				"limit":  req.Limit,
+			},
+		})
+
+	}
+}
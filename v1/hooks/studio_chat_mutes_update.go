@@ -0,0 +1,91 @@
+package hooks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatMutesUpdateReq struct {
+	MuteID    uint64 `json:"mute_id"`
+	UntilDate *int64 `json:"until_date"`
+	Reason    string `json:"reason"`
+}
+
+func StudioChatMutesUpdate(
+	chatService *services.ChatService,
+	moderationService *services.ModerationService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatMutesUpdateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Get the mute
+		mute, err := chatService.GetMuteByID(req.MuteID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if mute == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "mute not found"})
+			return
+		}
+
+		// Make sure the account can moderate the organization of the mute
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, mute.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Convert the until date, which is in seconds. A null until date makes the mute permanent
+		var untilDate *time.Time
+		if req.UntilDate != nil {
+			until := time.Unix(*req.UntilDate, 0)
+			untilDate = &until
+		}
+
+		// Update the mute
+		if err := chatService.UpdateMute(mute, untilDate, req.Reason); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Record the change in the moderation log
+		_, err = moderationService.RecordEvent(&services.ModerationEventInfo{
+			OrganizationID: mute.OrganizationID,
+			Actor:          services.AccountActor(account),
+			Action:         models.ModerationActionEditMute,
+			Target: &services.ChatUserInfo{
+				Username:  mute.Username.String,
+				IpAddress: mute.IpAddress.String,
+			},
+			Reason: req.Reason,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the updated mute
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeMutedUser(mute),
+		})
+
+	}
+}
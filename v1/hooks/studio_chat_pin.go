@@ -0,0 +1,75 @@
+package hooks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatPinReq struct {
+	ChatRoomIdentifier string `json:"chat_room_identifier"`
+	MessageID          string `json:"message_id"`
+	Message            string `json:"message"`
+	ExpireSeconds      int64  `json:"expire_seconds"`
+}
+
+func StudioChatPin(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+	socketsService *services.SocketsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatPinReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Get the chat room
+		chatRoom, err := chatService.GetChatRoomByIdentifier(req.ChatRoomIdentifier)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if chatRoom == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "chat room not found"})
+			return
+		}
+
+		// Make sure the account can moderate the chat room
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, chatRoom.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this chat room"})
+			return
+		}
+
+		// Determine when the pin expires, if ever
+		var untilDate *time.Time
+		if req.ExpireSeconds > 0 {
+			until := time.Now().Add(time.Second * time.Duration(req.ExpireSeconds))
+			untilDate = &until
+		}
+
+		// Pin the message
+		if err := socketsService.PinMessage(chatRoom, req.MessageID, req.Message, untilDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Otherwise return something successfully
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{},
+		})
+
+	}
+}
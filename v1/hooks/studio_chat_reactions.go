@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatReactionsReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+}
+
+func serializeReactions(organization *models.Organization) map[string]interface{} {
+	return map[string]interface{}{
+		"organization_id": organization.ID,
+		"reactions":       services.GetReactions(organization),
+		"is_default":      len(organization.Reactions) == 0,
+	}
+}
+
+func StudioChatReactions(
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatReactionsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get the organization
+		organization, err := organizationsService.GetOrganizationByID(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if organization == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+
+		// Return the reactions
+		c.JSON(http.StatusOK, gin.H{
+			"data": serializeReactions(organization),
+		})
+
+	}
+}
@@ -0,0 +1,120 @@
+package hooks
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	coreutils "github.com/connerdouglass/livechat-api/utils"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatRoomUpdateReq struct {
+	ChatRoomIdentifier string  `json:"chat_room_identifier"`
+	HoldMode           *string `json:"hold_mode"`
+	ProofOfWork        *bool   `json:"proof_of_work"`
+
+	// Restrictions on new chatters. Sending a negative followers-only time turns followers-only mode off.
+	NewChatterWaitMinutes *int64 `json:"new_chatter_wait_minutes"`
+	BlockNewChatterLinks  *bool  `json:"block_new_chatter_links"`
+	FollowersOnlyMinutes  *int64 `json:"followers_only_minutes"`
+
+	// How long senders can edit their messages for, where zero turns editing off
+	EditWindowSeconds *int64 `json:"edit_window_seconds"`
+}
+
+func serializeChatRoomSettings(chatRoom *models.ChatRoom) map[string]interface{} {
+	return map[string]interface{}{
+		"chat_room_identifier":     chatRoom.Identifier,
+		"hold_mode":                chatRoom.HoldMode,
+		"proof_of_work":            chatRoom.ProofOfWork,
+		"new_chatter_wait_minutes": chatRoom.NewChatterWaitMinutes,
+		"block_new_chatter_links":  chatRoom.BlockNewChatterLinks,
+		"followers_only_minutes":   coreutils.FlattenNullInt64(chatRoom.FollowersOnlyMinutes),
+		"edit_window_seconds":      chatRoom.EditWindowSeconds,
+	}
+}
+
+func StudioChatRoomUpdate(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatRoomUpdateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the settings are valid
+		if req.HoldMode != nil && !models.IsValidHoldMode(*req.HoldMode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hold mode"})
+			return
+		}
+		if req.NewChatterWaitMinutes != nil && *req.NewChatterWaitMinutes < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "new chatter wait cannot be negative"})
+			return
+		}
+		if req.EditWindowSeconds != nil &&
+			(*req.EditWindowSeconds < 0 || *req.EditWindowSeconds > models.MaxEditWindowSeconds) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "edit window is out of range"})
+			return
+		}
+
+		// Get the chat room
+		chatRoom, err := chatService.GetChatRoomByIdentifier(req.ChatRoomIdentifier)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if chatRoom == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "chat room not found"})
+			return
+		}
+
+		// Make sure the account can moderate the chat room
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, chatRoom.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this chat room"})
+			return
+		}
+
+		// Followers-only mode is turned off with a negative time
+		var followersOnly *sql.NullInt64
+		if req.FollowersOnlyMinutes != nil {
+			followersOnly = &sql.NullInt64{}
+			if *req.FollowersOnlyMinutes >= 0 {
+				followersOnly = &sql.NullInt64{Valid: true, Int64: *req.FollowersOnlyMinutes}
+			}
+		}
+
+		// Update the settings
+		err = chatService.UpdateChatRoomSettings(chatRoom, &services.ChatRoomSettings{
+			HoldMode:              req.HoldMode,
+			ProofOfWork:           req.ProofOfWork,
+			NewChatterWaitMinutes: req.NewChatterWaitMinutes,
+			BlockNewChatterLinks:  req.BlockNewChatterLinks,
+			FollowersOnlyMinutes:  followersOnly,
+			EditWindowSeconds:     req.EditWindowSeconds,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the new settings
+		c.JSON(http.StatusOK, gin.H{
+			"data": serializeChatRoomSettings(chatRoom),
+		})
+
+	}
+}
@@ -3,18 +3,25 @@ package hooks
 import (
 	"net/http"
 
+	"github.com/connerdouglass/livechat-api/models"
 	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
 	"github.com/gin-gonic/gin"
 )
 
 type StudioChatUnmuteReq struct {
 	OrganizationID uint64                `json:"organization_id"`
+	MuteID         uint64                `json:"mute_id"`
 	User           services.ChatUserInfo `json:"user"`
+	Reason         string                `json:"reason"`
+	GroupIpv6      bool                  `json:"group_ipv6"`
 }
 
 func StudioChatUnmute(
 	accountsService *services.AccountsService,
 	chatService *services.ChatService,
+	moderationService *services.ModerationService,
+	organizationsService *services.OrganizationsService,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -25,11 +32,64 @@ func StudioChatUnmute(
 			return
 		}
 
-		// Get the account sending the request
-		// account := utils.CtxGetAccount(c)
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// If a mute ID is provided, lift just that mute
+		if req.MuteID > 0 {
+			mute, err := chatService.GetMuteByID(req.MuteID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if mute == nil || mute.OrganizationID != req.OrganizationID {
+				c.JSON(http.StatusNotFound, gin.H{"error": "mute not found"})
+				return
+			}
+			if err := chatService.DeleteMute(mute); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			req.User = services.ChatUserInfo{
+				Username:  mute.Username.String,
+				IpAddress: mute.IpAddress.String,
+			}
+		} else {
 
-		// Mute the user on the chat
-		if err := chatService.UnmuteUser(req.OrganizationID, &req.User); err != nil {
+			// Make sure the IP address or range is valid
+			if len(req.User.IpAddress) > 0 {
+				if _, err := services.NormalizeMuteIpAddress(req.User.IpAddress, req.GroupIpv6); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+			}
+
+			// Unmute the user on the chat
+			if err := chatService.UnmuteUser(req.OrganizationID, &req.User, req.GroupIpv6); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+		}
+
+		// Record the action in the moderation log
+		_, err = moderationService.RecordEvent(&services.ModerationEventInfo{
+			OrganizationID: req.OrganizationID,
+			Actor:          services.AccountActor(account),
+			Action:         models.ModerationActionUnmute,
+			Target:         &req.User,
+			Reason:         req.Reason,
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
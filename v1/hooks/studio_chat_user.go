@@ -0,0 +1,58 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioChatUserReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+	Username       string `json:"username"`
+}
+
+func StudioChatUser(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioChatUserReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get the profile of the chatter
+		chatUser, err := chatService.GetChatUser(req.OrganizationID, req.Username)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if chatUser == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "chat user not found"})
+			return
+		}
+
+		// Return the profile
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeChatUser(chatUser),
+		})
+
+	}
+}
@@ -0,0 +1,61 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioEmotesReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+}
+
+func StudioEmotes(
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioEmotesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get the emotes
+		emotes, err := chatService.GetEmotes(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serialize the emotes
+		emotesSer := make([]map[string]interface{}, len(emotes))
+		for i, emote := range emotes {
+			emotesSer[i] = services.SerializeEmote(emote)
+		}
+
+		// Return the emotes
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"emotes": emotesSer,
+			},
+		})
+
+	}
+}
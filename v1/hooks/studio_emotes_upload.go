@@ -0,0 +1,76 @@
+package hooks
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// StudioEmotesUpload creates an emote from an uploaded image. Like the badge image upload, it takes a multipart form
+// with the organization_id and code fields, and the image in the image field.
+func StudioEmotesUpload(
+	assetsService *services.AssetsService,
+	chatService *services.ChatService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Limit the size of the upload
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageFormBytes)
+
+		// Get the fields of the form
+		organizationID, err := strconv.ParseUint(c.PostForm("organization_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization_id"})
+			return
+		}
+		code := c.PostForm("code")
+		if err := services.ValidateEmoteCode(code); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, organizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Read the uploaded image
+		data, err := readFormImage(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Resize and store the image
+		imageURL, err := assetsService.StoreEmoteImage(data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Create the emote
+		emote, err := chatService.AddEmote(organizationID, code, imageURL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the new emote
+		c.JSON(http.StatusOK, gin.H{
+			"data": services.SerializeEmote(emote),
+		})
+
+	}
+}
@@ -0,0 +1,127 @@
+package hooks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	coreutils "github.com/connerdouglass/livechat-api/utils"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioModerationEventsReq struct {
+	OrganizationID  uint64 `json:"organization_id"`
+	ChatRoomID      uint64 `json:"chat_room_id"`
+	ActorType       string `json:"actor_type"`
+	ActorAccountID  uint64 `json:"actor_account_id"`
+	Action          string `json:"action"`
+	TargetUsername  string `json:"target_username"`
+	TargetIpAddress string `json:"target_ip_address"`
+	Since           int64  `json:"since"`
+	Until           int64  `json:"until"`
+	Offset          int    `json:"offset"`
+	Limit           int    `json:"limit"`
+}
+
+// Filter converts the request to a moderation event filter
+func (req *StudioModerationEventsReq) Filter() *services.ModerationEventFilter {
+	filter := services.ModerationEventFilter{
+		OrganizationID:  req.OrganizationID,
+		ChatRoomID:      req.ChatRoomID,
+		ActorType:       req.ActorType,
+		ActorAccountID:  req.ActorAccountID,
+		Action:          req.Action,
+		TargetUsername:  req.TargetUsername,
+		TargetIpAddress: req.TargetIpAddress,
+		Offset:          req.Offset,
+		Limit:           req.Limit,
+	}
+	if req.Since > 0 {
+		since := time.Unix(req.Since, 0)
+		filter.SinceDate = &since
+	}
+	if req.Until > 0 {
+		until := time.Unix(req.Until, 0)
+		filter.UntilDate = &until
+	}
+	return &filter
+}
+
+func StudioModerationEvents(
+	moderationService *services.ModerationService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioModerationEventsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Keep the page size within reason
+		if req.Limit <= 0 || req.Limit > 100 {
+			req.Limit = 50
+		}
+		if req.Offset < 0 {
+			req.Offset = 0
+		}
+
+		// Get the page of events
+		events, total, err := moderationService.ListEvents(req.Filter())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serialize all of the events
+		eventsSer := make([]map[string]interface{}, len(events))
+		for i, event := range events {
+			eventsSer[i] = serializeModerationEvent(event)
+		}
+
+		// Return the page of events
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"events": eventsSer,
+				"total":  total,
+				"offset": req.Offset,
+				"limit":  req.Limit,
+			},
+		})
+
+	}
+}
+
+func serializeModerationEvent(event *models.ModerationEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                event.ID,
+		"organization_id":   event.OrganizationID,
+		"chat_room_id":      coreutils.FlattenNullInt64(event.ChatRoomID),
+		"actor_type":        event.ActorType,
+		"actor_account_id":  coreutils.FlattenNullInt64(event.ActorAccountID),
+		"actor_rule":        coreutils.FlattenNullString(event.ActorRule),
+		"actor_username":    coreutils.FlattenNullString(event.ActorUsername),
+		"action":            event.Action,
+		"target_username":   coreutils.FlattenNullString(event.TargetUsername),
+		"target_ip_address": coreutils.FlattenNullString(event.TargetIpAddress),
+		"reason":            event.Reason,
+		"message_id":        coreutils.FlattenNullString(event.MessageID),
+		"created_date":      event.CreatedDate.UTC().Unix(),
+	}
+}
@@ -0,0 +1,116 @@
+package hooks
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// moderationEventsCsvHeader is the header row of the moderation log CSV export
+var moderationEventsCsvHeader = []string{
+	"id",
+	"created_date",
+	"chat_room_id",
+	"actor_type",
+	"actor_account_id",
+	"actor_rule",
+	"actor_username",
+	"action",
+	"target_username",
+	"target_ip_address",
+	"reason",
+	"message_id",
+}
+
+func StudioModerationEventsExport(
+	moderationService *services.ModerationService,
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioModerationEventsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account can moderate the organization
+		account := utils.CtxGetAccount(c)
+		canModerate, err := organizationsService.CanAccountModerate(account, req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canModerate {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to moderate this organization"})
+			return
+		}
+
+		// Get every matching event, ignoring paging
+		filter := req.Filter()
+		filter.Offset = 0
+		filter.Limit = 0
+		events, _, err := moderationService.ListEvents(filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Write the events out as CSV
+		filename := fmt.Sprintf("moderation-log-%d-%s.csv", req.OrganizationID, time.Now().UTC().Format("20060102"))
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		c.Status(http.StatusOK)
+		writer := csv.NewWriter(c.Writer)
+		writer.Write(moderationEventsCsvHeader)
+		for _, event := range events {
+			writer.Write(moderationEventCsvRow(event))
+		}
+		writer.Flush()
+
+	}
+}
+
+// csvFormulaPrefixes are the characters that make spreadsheets read a cell as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvText escapes text written by viewers, so a spreadsheet shows it as text instead of running it as a formula
+func csvText(text string) string {
+	if len(text) > 0 && strings.ContainsRune(csvFormulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func moderationEventCsvRow(event *models.ModerationEvent) []string {
+	nullInt := func(val sql.NullInt64) string {
+		if !val.Valid {
+			return ""
+		}
+		return strconv.FormatInt(val.Int64, 10)
+	}
+	return []string{
+		strconv.FormatUint(event.ID, 10),
+		event.CreatedDate.UTC().Format(time.RFC3339),
+		nullInt(event.ChatRoomID),
+		event.ActorType,
+		nullInt(event.ActorAccountID),
+		csvText(event.ActorRule.String),
+		csvText(event.ActorUsername.String),
+		event.Action,
+		csvText(event.TargetUsername.String),
+		event.TargetIpAddress.String,
+		csvText(event.Reason),
+		event.MessageID.String,
+	}
+}
@@ -0,0 +1,86 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func StudioPrivacyErase(
+	moderationService *services.ModerationService,
+	organizationsService *services.OrganizationsService,
+	privacyService *services.PrivacyService,
+	socketsService *services.SocketsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioPrivacyUserReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(req.User.Username) == 0 && len(req.User.IpAddress) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a username or IP address is required"})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Remove the user's messages from the live chat rooms, and tell viewers to revoke them
+		if err := socketsService.PurgeOrganizationUserMessages(req.OrganizationID, &req.User); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Unpin the user's messages, and tell viewers to remove them
+		chatRooms, err := privacyService.GetPinnedChatRooms(req.OrganizationID, &req.User)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, chatRoom := range chatRooms {
+			if err := socketsService.UnpinMessage(chatRoom); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// Erase everything stored about the user
+		if err := privacyService.EraseUserData(req.OrganizationID, &req.User); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Record the erasure in the moderation log, without the details that were just erased
+		_, err = moderationService.RecordEvent(&services.ModerationEventInfo{
+			OrganizationID: req.OrganizationID,
+			Actor:          services.AccountActor(account),
+			Action:         models.ModerationActionErase,
+			Reason:         "data erasure request",
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Otherwise return something successfully
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{},
+		})
+
+	}
+}
@@ -0,0 +1,101 @@
+package hooks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioPrivacyUserReq struct {
+	OrganizationID uint64                `json:"organization_id"`
+	User           services.ChatUserInfo `json:"user"`
+}
+
+func StudioPrivacyExport(
+	organizationsService *services.OrganizationsService,
+	privacyService *services.PrivacyService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioPrivacyUserReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(req.User.Username) == 0 && len(req.User.IpAddress) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a username or IP address is required"})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get all of the data held about the user
+		data, err := privacyService.ExportUserData(req.OrganizationID, &req.User)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Serialize all of the data
+		var chatUserSer interface{}
+		if data.ChatUser != nil {
+			chatUserSer = services.SerializeChatUser(data.ChatUser)
+		}
+		messagesSer := make([]map[string]interface{}, len(data.Messages))
+		for i, message := range data.Messages {
+			messagesSer[i] = services.SerializeChatMessage(message)
+		}
+		mutesSer := make([]map[string]interface{}, len(data.Mutes))
+		for i, mute := range data.Mutes {
+			mutesSer[i] = services.SerializeMutedUser(mute)
+		}
+		eventsSer := make([]map[string]interface{}, len(data.ModerationEvents))
+		for i, event := range data.ModerationEvents {
+			eventsSer[i] = serializeModerationEvent(event)
+		}
+		badgesSer := make([]map[string]interface{}, len(data.BadgeAssignments))
+		for i, assignment := range data.BadgeAssignments {
+			badgesSer[i] = services.SerializeBadgeAssignment(assignment)
+		}
+		pinsSer := make([]map[string]interface{}, len(data.PinnedChatRooms))
+		for i, chatRoom := range data.PinnedChatRooms {
+			pinsSer[i] = map[string]interface{}{
+				"chat_room_identifier": chatRoom.Identifier,
+				"id":                   chatRoom.PinnedMessageID.String,
+				"username":             chatRoom.PinnedUsername.String,
+				"photo_url":            chatRoom.PinnedPhotoUrl.String,
+				"message":              chatRoom.PinnedMessage.String,
+			}
+		}
+
+		// Return the export
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"user":              req.User,
+				"exported_date":     time.Now().UTC().Unix(),
+				"profile":           chatUserSer,
+				"messages":          messagesSer,
+				"mutes":             mutesSer,
+				"moderation_events": eventsSer,
+				"badge_assignments": badgesSer,
+				"pinned_messages":   pinsSer,
+			},
+		})
+
+	}
+}
@@ -0,0 +1,66 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	coreutils "github.com/connerdouglass/livechat-api/utils"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioPrivacyRetentionReq struct {
+	OrganizationID uint64 `json:"organization_id"`
+}
+
+func StudioPrivacyRetention(
+	organizationsService *services.OrganizationsService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioPrivacyRetentionReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the organization
+		organization, err := organizationsService.GetOrganizationByID(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if organization == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+
+		// Return the retention windows
+		c.JSON(http.StatusOK, gin.H{
+			"data": serializeRetention(organization),
+		})
+
+	}
+}
+
+func serializeRetention(organization *models.Organization) map[string]interface{} {
+	return map[string]interface{}{
+		"message_retention_days": coreutils.FlattenNullInt64(organization.MessageRetentionDays),
+		"mute_retention_days":    coreutils.FlattenNullInt64(organization.MuteRetentionDays),
+		"log_retention_days":     coreutils.FlattenNullInt64(organization.LogRetentionDays),
+	}
+}
@@ -0,0 +1,81 @@
+package hooks
+
+import (
+	"net/http"
+
+	"github.com/connerdouglass/livechat-api/models"
+	"github.com/connerdouglass/livechat-api/services"
+	"github.com/connerdouglass/livechat-api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type StudioPrivacyRetentionUpdateReq struct {
+	OrganizationID       uint64 `json:"organization_id"`
+	MessageRetentionDays *int64 `json:"message_retention_days"`
+	MuteRetentionDays    *int64 `json:"mute_retention_days"`
+	LogRetentionDays     *int64 `json:"log_retention_days"`
+}
+
+func StudioPrivacyRetentionUpdate(
+	organizationsService *services.OrganizationsService,
+	retentionService *services.RetentionService,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get the request body
+		var req StudioPrivacyRetentionUpdateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Retention windows can't be negative
+		for _, days := range []*int64{req.MessageRetentionDays, req.MuteRetentionDays, req.LogRetentionDays} {
+			if days != nil && *days < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "retention days cannot be negative"})
+				return
+			}
+		}
+
+		// Make sure the account is an admin of the organization
+		account := utils.CtxGetAccount(c)
+		isAdmin, err := organizationsService.AccountHasRole(account, req.OrganizationID, models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this organization"})
+			return
+		}
+
+		// Get the organization
+		organization, err := organizationsService.GetOrganizationByID(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if organization == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+
+		// Update the retention windows
+		err = retentionService.UpdateRetention(
+			organization,
+			req.MessageRetentionDays,
+			req.MuteRetentionDays,
+			req.LogRetentionDays,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Return the new retention windows
+		c.JSON(http.StatusOK, gin.H{
+			"data": serializeRetention(organization),
+		})
+
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/connerdouglass/livechat-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveIpAddress creates a middleware function that resolves the client's IP address and adds it to the context
+func ResolveIpAddress(ipResolver *utils.IpResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Resolve the IP address from the headers and the connection
+		c.Set("ip_address", ipResolver.GetIpAddress(c.Request.Header, stringAddr(c.Request.RemoteAddr)))
+
+		// Move to the next
+		c.Next()
+
+	}
+}
+
+// stringAddr wraps the remote address string of an HTTP request as a net.Addr
+type stringAddr string
+
+func (a stringAddr) Network() string {
+	return "tcp"
+}
+
+func (a stringAddr) String() string {
+	return string(a)
+}
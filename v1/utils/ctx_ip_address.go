@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// CtxGetIpAddress gets the resolved IP address of the client from a Gin context
+func CtxGetIpAddress(c *gin.Context) string {
+
+	// Get the IP address from the context
+	ipAddress, exists := c.Get("ip_address")
+	if !exists || ipAddress == nil {
+		return ""
+	}
+
+	// Perform a typecheck on the IP address
+	str, ok := ipAddress.(string)
+	if !ok {
+		return ""
+	}
+
+	// Return the IP address
+	return str
+
+}